@@ -20,3 +20,13 @@ cp ../click/manifest.json . # Allow clickable to find the manifest easily
 cp ../click/* ./tmp/
 cp ../images/* ./tmp/falcon
 cp ../src/*.ini ./tmp/falcon
+
+echo "Compiling translations"
+
+for po in ../po/*.po; do
+    [ -e "$po" ] || continue
+
+    lang=`basename $po .po`
+    mkdir -p ./tmp/share/locale/$lang/LC_MESSAGES
+    msgfmt $po -o ./tmp/share/locale/$lang/LC_MESSAGES/falcon.bhdouglass.mo
+done
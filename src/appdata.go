@@ -0,0 +1,131 @@
+package main
+
+import (
+    "fmt"
+    "io/ioutil"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+//Returns the directories removed by a clear action, "cache" only clears the cache while "all" clears everything
+func (falcon *Falcon) clearDirectories(pkg string, mode string) []AppDirectory {
+    var directories []AppDirectory
+
+    for _, directory := range falcon.appDirectories(pkg) {
+        if mode == "all" || directory.Name == mode {
+            directories = append(directories, directory)
+        }
+    }
+
+    return directories
+}
+
+//Makes sure a path is one of the app's own XDG directories before anything gets removed
+func (falcon *Falcon) isAppDirectory(pkg string, path string) bool {
+    if pkg == "" || pkg == "." || pkg == ".." || strings.Contains(pkg, "/") {
+        return false
+    }
+
+    path = filepath.Clean(path)
+    for _, directory := range falcon.appDirectories(pkg) {
+        if path == filepath.Clean(directory.Path) {
+            return true
+        }
+    }
+
+    return false
+}
+
+func (falcon *Falcon) clearDirectory(pkg string, path string) error {
+    if !falcon.isAppDirectory(pkg, path) {
+        return fmt.Errorf("Refusing to clear %s, it does not belong to %s", path, pkg)
+    }
+
+    if info, err := os.Lstat(path); err != nil {
+        if os.IsNotExist(err) {
+            return nil
+        }
+
+        return err
+    } else if !info.IsDir() {
+        return fmt.Errorf("Refusing to clear %s, it is not a directory", path)
+    }
+
+    //Only the contents are removed so the app can still write to its directory
+    files, err := ioutil.ReadDir(path)
+    if err != nil {
+        return err
+    }
+
+    for _, f := range files {
+        if err := os.RemoveAll(filepath.Join(path, f.Name())); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+func (falcon *Falcon) clearDataPreview(app Application, mode string, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply) error {
+    titleWidget := scopes.NewPreviewWidget("title", "header")
+    if mode == "all" {
+        titleWidget.AddAttributeValue("title", "Clear all data")
+    } else {
+        titleWidget.AddAttributeValue("title", "Clear cache")
+    }
+    titleWidget.AddAttributeValue("subtitle", app.Title)
+
+    var lines []string
+    for _, directory := range falcon.clearDirectories(app.Package, mode) {
+        lines = append(lines, fmt.Sprintf("%s (%s)", directory.Path, formatSize(directorySize(directory.Path))))
+    }
+
+    pathsWidget := scopes.NewPreviewWidget("paths", "text")
+    pathsWidget.AddAttributeValue("title", "The contents of these directories will be deleted")
+    pathsWidget.AddAttributeValue("text", strings.Join(lines, "<br>"))
+
+    var buttons []ActionInfo
+    buttons = append(buttons, ActionInfo{Id: "data:clear-" + mode, Label: "Delete"})
+    buttons = append(buttons, ActionInfo{Id: "data:cancel", Label: "Cancel"})
+
+    actionsWidget := scopes.NewPreviewWidget("actions", "actions")
+    actionsWidget.AddAttributeValue("actions", buttons)
+
+    return falcon.pushPreview(metadata, reply, PreviewColumns{
+        Primary: []scopes.PreviewWidget{titleWidget, actionsWidget},
+        Details: []scopes.PreviewWidget{pathsWidget},
+    })
+}
+
+func (falcon *Falcon) appDataPerformAction(result *scopes.Result, metadata *scopes.ActionMetadata, widgetId, actionId string) *scopes.ActivationResponse {
+    var app Application
+    if err := result.Get("app", &app); err != nil {
+        log.Println(err)
+    }
+
+    resp := scopes.NewActivationResponse(scopes.ActivationShowPreview)
+
+    if actionId == "data:confirm-cache" {
+        resp.SetScopeData(PreviewState{Clear: "cache"})
+    } else if actionId == "data:confirm-all" {
+        resp.SetScopeData(PreviewState{Clear: "all"})
+    } else if actionId == "data:clear-cache" || actionId == "data:clear-all" {
+        mode := strings.TrimPrefix(actionId, "data:clear-")
+
+        for _, directory := range falcon.clearDirectories(app.Package, mode) {
+            if err := falcon.clearDirectory(app.Package, directory.Path); err != nil {
+                log.Printf("Error while clearing %s:", directory.Path)
+                log.Println(err)
+            } else {
+                log.Printf("Cleared %s", directory.Path)
+            }
+        }
+
+        falcon.forgetDiskUsage(app.Package)
+    }
+
+    return resp
+}
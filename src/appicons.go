@@ -0,0 +1,214 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "strings"
+)
+
+//Where the icon theme is searched for app icons, in order of preference
+var iconThemeDirectories = []string{
+    "/usr/share/icons/suru/apps/scalable/",
+    "/usr/share/icons/suru/apps/128/",
+    "/usr/share/icons/hicolor/scalable/apps/",
+    "/usr/share/icons/hicolor/128x128/apps/",
+    "/usr/share/icons/hicolor/64x64/apps/",
+    "/usr/share/pixmaps/",
+}
+
+var iconThemeExtensions = []string{".svg", ".png"}
+
+type IconCandidate struct {
+    Label string
+    Path  string
+}
+
+//Names the app's icon could have in the icon theme
+func (falcon *Falcon) iconThemeNames(app Application) []string {
+    var names []string
+
+    if app.IconName != "" && app.IconName[0:1] != "/" {
+        names = append(names, app.IconName)
+    }
+
+    names = append(names, app.Id)
+
+    split := strings.Split(app.Id, ".")
+    if len(split) > 1 {
+        names = append(names, split[len(split) - 1])
+    }
+
+    return names
+}
+
+//A short name for a theme directory (ie: suru/apps/128), the same icon name can be found in several of them
+func iconThemeLabel(dir string) string {
+    dir = strings.TrimPrefix(dir, "/usr/share/icons/")
+    dir = strings.TrimPrefix(dir, "/usr/share/")
+
+    return strings.Trim(dir, "/")
+}
+
+func (falcon *Falcon) iconCandidates(app Application) []IconCandidate {
+    var candidates []IconCandidate
+    found := map[string]bool{}
+
+    for _, iconPack := range falcon.installedIconPacks() {
+        content, err := ioutil.ReadFile(iconPack.Icons + "/icon-pack.json")
+        if err != nil {
+            log.Println(err)
+            continue
+        }
+
+        mapping, _ := parseIconPackMap(content)
+        if icon, ok := mapping.Match(appIconKeys(app)); ok {
+            path := iconPack.Icons + "/" + icon
+            if !found[path] && checkIconFile(path) == nil {
+                found[path] = true
+                candidates = append(candidates, IconCandidate{Label: iconPack.Title, Path: path})
+            }
+        }
+    }
+
+    for _, name := range falcon.iconThemeNames(app) {
+        for _, dir := range iconThemeDirectories {
+            for _, extension := range iconThemeExtensions {
+                path := dir + name + extension
+                if !found[path] && checkIconFile(path) == nil {
+                    found[path] = true
+                    candidates = append(candidates, IconCandidate{Label: fmt.Sprintf("Theme: %s%s (%s)", name, extension, iconThemeLabel(dir)), Path: path})
+                }
+            }
+        }
+    }
+
+    return candidates
+}
+
+func (falcon *Falcon) iconPickerPreview(app Application, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply) error {
+    titleWidget := scopes.NewPreviewWidget("title", "header")
+    titleWidget.AddAttributeValue("title", "Change icon")
+    titleWidget.AddAttributeValue("subtitle", app.Title)
+
+    candidates := falcon.iconCandidates(app)
+
+    var sources []string
+    var buttons []ActionInfo
+    for _, candidate := range candidates {
+        sources = append(sources, candidate.Path)
+        buttons = append(buttons, ActionInfo{Id: "app-icon:set:" + candidate.Path, Label: candidate.Label})
+    }
+
+    if _, ok := falcon.iconOverrides[app.Id]; ok {
+        buttons = append(buttons, ActionInfo{Id: "app-icon:reset", Label: "Reset"})
+    }
+    buttons = append(buttons, ActionInfo{Id: "app-icon:cancel", Label: "Cancel"})
+
+    columns := PreviewColumns{
+        Primary: []scopes.PreviewWidget{titleWidget},
+    }
+
+    if len(candidates) > 0 {
+        galleryWidget := scopes.NewPreviewWidget("candidates", "gallery")
+        galleryWidget.AddAttributeValue("sources", sources)
+
+        columns.Primary = append(columns.Primary, galleryWidget)
+    } else {
+        emptyWidget := scopes.NewPreviewWidget("candidates", "text")
+        emptyWidget.AddAttributeValue("text", "No icons were found for this app in the installed icon packs or the icon theme")
+
+        columns.Primary = append(columns.Primary, emptyWidget)
+    }
+
+    actionsWidget := scopes.NewPreviewWidget("actions", "actions")
+    actionsWidget.AddAttributeValue("actions", buttons)
+    columns.Primary = append(columns.Primary, actionsWidget)
+
+    pathInput := scopes.NewPreviewWidget("app-icon:path", "comment-input")
+    pathInput.AddAttributeValue("submit-label", "Use a file path")
+    columns.Details = append(columns.Details, pathInput)
+
+    return falcon.pushPreview(metadata, reply, columns)
+}
+
+func (falcon *Falcon) appIconPerformAction(result *scopes.Result, metadata *scopes.ActionMetadata, widgetId, actionId string) *scopes.ActivationResponse {
+    var resp *scopes.ActivationResponse
+
+    var app Application
+    if err := result.Get("app", &app); err != nil {
+        log.Println(err)
+    }
+
+    if actionId == "app-icon:change" {
+        resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+        resp.SetScopeData(PreviewState{Icon: true})
+    } else if actionId == "app-icon:cancel" {
+        resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+    } else {
+        path := ""
+        if actionId == "commented" {
+            var input map[string]interface{}
+            if err := metadata.ScopeData(&input); err != nil {
+                log.Println(err)
+            }
+
+            if comment, ok := input["comment"].(string); ok {
+                path = strings.TrimPrefix(strings.TrimSpace(comment), "file://")
+            }
+        } else if strings.HasPrefix(actionId, "app-icon:set:") {
+            path = strings.TrimPrefix(actionId, "app-icon:set:")
+        }
+
+        if actionId == "app-icon:reset" {
+            delete(falcon.iconOverrides, app.Id)
+            falcon.saveIconOverrides()
+        } else if err := checkIconFile(path); err != nil || app.Id == "" {
+            log.Printf("Not using %s as the icon of %s: %v", path, app.Id, err)
+
+            resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+            resp.SetScopeData(PreviewState{Icon: true})
+            return resp
+        } else {
+            falcon.iconOverrides[app.Id] = path
+            falcon.saveIconOverrides()
+        }
+
+        //redirect to blank search
+        query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
+        resp = scopes.NewActivationResponseForQuery(query)
+    }
+
+    return resp
+}
+
+func (falcon *Falcon) iconOverride(id string) (string, bool) {
+    if icon, ok := falcon.iconOverrides[id]; ok {
+        if _, err := iconStat(icon); err == nil {
+            return icon, true
+        }
+    }
+
+    return "", false
+}
+
+func (falcon *Falcon) saveIconOverrides() {
+    if err := writeJsonFile(falcon.iconOverridesFile, falcon.iconOverrides); err != nil {
+        log.Println(err)
+    }
+
+    falcon.resetIconCache()
+}
+
+func (falcon *Falcon) loadIconOverrides() {
+    falcon.iconOverrides = map[string]string{}
+
+    content, err := ioutil.ReadFile(falcon.iconOverridesFile)
+    if err != nil {
+        log.Println(err)
+    } else if err := json.Unmarshal(content, &falcon.iconOverrides); err != nil {
+        log.Println(err)
+    }
+}
@@ -9,33 +9,11 @@ import (
     "log"
     "os"
     "os/exec"
-    "sort"
+    "path/filepath"
     "strings"
 )
 
-const searchCategoryTemplate = `{
-    "schema-version": 1,
-    "template": {
-        "category-layout": "%s",
-        "collapsed-rows": 0,
-        "card-layout": "%s",
-        "card-size": "%s"
-    },
-    "components" : {
-        "title": "title",
-        "subtitle": "subtitle",
-        "art": {
-            "field": "art",
-            "aspect-ratio": 1.13
-        }
-    }
-}`
-
-func (falcon *Falcon) firstChar(str string) string {
-    return string([]rune(str)[0])
-}
-
-func (falcon *Falcon) appPreview(result *scopes.Result, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply) error {
+func (falcon *Falcon) appPreview(result *scopes.Result, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply, cancelled <-chan bool) error {
     var settings Settings
     falcon.base.Settings(&settings)
 
@@ -44,6 +22,23 @@ func (falcon *Falcon) appPreview(result *scopes.Result, metadata *scopes.ActionM
         log.Println(err)
     }
 
+    var state PreviewState
+    if err := metadata.ScopeData(&state); err != nil {
+        log.Println(err)
+    }
+
+    if app.IsCustom && state.Editing {
+        return falcon.customEditorPreview(falcon.customDrafts.Get(customDraftKey(result)), metadata, reply)
+    } else if state.Group {
+        return falcon.groupPreview(app, metadata, reply)
+    } else if state.Clear != "" && app.Package != "" {
+        return falcon.clearDataPreview(app, state.Clear, metadata, reply)
+    } else if state.Uninstall && app.Package != "" {
+        return falcon.uninstallPreview(app, metadata, reply)
+    } else if state.Icon {
+        return falcon.iconPickerPreview(app, metadata, reply)
+    }
+
     headerWidget := scopes.NewPreviewWidget("header", "header")
     headerWidget.AddAttributeValue("title", app.Title)
 
@@ -67,15 +62,60 @@ func (falcon *Falcon) appPreview(result *scopes.Result, metadata *scopes.ActionM
         buttons = append(buttons, ActionInfo{Id: "favorite", Label: "Favorite"})
     }
 
+    if app.IsNew {
+        buttons = append(buttons, ActionInfo{Id: "new:dismiss", Label: "Dismiss new"})
+    }
+
+    if app.HasUpdate {
+        uri := falcon.storeProvider(settings).InstallUri(StoreApp{Id: app.Package})
+        buttons = append(buttons, ActionInfo{Id: "store:open", Uri: uri, Label: "Open in store"})
+    }
+
+    if settings.Layout == 2 {
+        buttons = append(buttons, ActionInfo{Id: "group:change", Label: "Change group"})
+    }
+
+    buttons = append(buttons, ActionInfo{Id: "app-icon:change", Label: "Change icon"})
+
+    if app.Package != "" {
+        buttons = append(buttons, ActionInfo{Id: "data:confirm-cache", Label: "Clear cache"})
+        buttons = append(buttons, ActionInfo{Id: "data:confirm-all", Label: "Clear all data"})
+        buttons = append(buttons, ActionInfo{Id: "uninstall:confirm", Label: "Uninstall"})
+    }
+
+    if app.IsCustom {
+        buttons = append(buttons, ActionInfo{Id: "custom:edit", Label: "Edit"})
+        buttons = append(buttons, ActionInfo{Id: "custom:delete", Label: "Delete"})
+    }
+
     actionsWidget := scopes.NewPreviewWidget("actions", "actions")
     actionsWidget.AddAttributeValue("actions", buttons)
 
-    return reply.PushWidgets(headerWidget, iconWidget, commentWidget, idWidget, actionsWidget)
+    columns := PreviewColumns{
+        Primary: []scopes.PreviewWidget{iconWidget, headerWidget, actionsWidget},
+        Details: []scopes.PreviewWidget{commentWidget, idWidget},
+    }
+
+    if app.Package != "" {
+        columns.Extra = append(columns.Extra, falcon.clickDetailsWidgets(app)...)
+        columns.Extra = append(columns.Extra, falcon.diskUsageWidget(app))
+    }
+
+    if err := falcon.pushPreview(metadata, reply, columns); err != nil {
+        return err
+    }
+
+    if app.Package != "" {
+        falcon.pushDiskUsage(app, reply, cancelled)
+    }
+
+    return nil
 }
 
 //TODO cache this data
 func (falcon *Falcon) getLibertineApps(query string) Applications {
     var appList Applications
+    var containers []LibertineContainer
 
     //Note, we don't want to fail if we encounter an error in here (hence the long chain of if/else)
     containerOutput, err := exec.Command("libertine-container-manager", "list").Output()
@@ -98,6 +138,12 @@ func (falcon *Falcon) getLibertineApps(query string) Applications {
                         log.Printf("Error while decoding apps in %s:", containerList[index])
                         log.Println(err)
                     } else {
+                        container := LibertineContainer{Id: containerList[index], Name: libertineApps.Name}
+                        if container.Name == "" {
+                            container.Name = container.Id
+                        }
+                        containers = append(containers, container)
+
                         for jindex := range libertineApps.AppLaunchers {
                             if !libertineApps.AppLaunchers[jindex].NoDisplay {
                                 //log.Printf("libertine app: %s", libertineApps.AppLaunchers[jindex].Name)
@@ -110,12 +156,22 @@ func (falcon *Falcon) getLibertineApps(query string) Applications {
                                 var libertineApp Application
                                 libertineApp.Id = id
                                 libertineApp.Title = libertineApps.AppLaunchers[jindex].Name
-                                libertineApp.Sort = strings.ToLower(libertineApps.AppLaunchers[jindex].Name)
                                 libertineApp.Comment = ""
-                                libertineApp.Icon = falcon.getIcon(id, libertineApps.AppLaunchers[jindex].Icons[0])
+                                libertineApp.DesktopId = filepath.Base(libertineApps.AppLaunchers[jindex].DesktopFileName)
+
+                                icon := libertineApps.AppLaunchers[jindex].Icons[0]
+                                libertineApp.IconName = strings.TrimSuffix(filepath.Base(icon), filepath.Ext(icon))
+                                libertineApp.SourceIcon = icon
+                                libertineApp.Icon = falcon.getAppIcon(libertineApp, icon)
                                 libertineApp.Uri = fmt.Sprintf("appid://%s/%s/0.0", containerList[index], id)
                                 libertineApp.IsApp = true
                                 libertineApp.IsDesktop = true
+                                libertineApp.Container = containerList[index]
+                                libertineApp.Source = "libertine"
+
+                                if info, err := os.Stat(libertineApps.AppLaunchers[jindex].DesktopFileName); err == nil {
+                                    libertineApp.Installed = info.ModTime().Unix()
+                                }
 
                                 if (query == "" || strings.Index(strings.ToLower(libertineApp.Title), strings.ToLower(query)) >= 0) {
                                     appList = append(appList, libertineApp)
@@ -128,25 +184,21 @@ func (falcon *Falcon) getLibertineApps(query string) Applications {
         }
     }
 
+    falcon.containers = containers
+
     return appList
 }
 
-func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
-    var settings Settings
-    falcon.base.Settings(&settings)
-
-    paths := []string{
-        "/usr/share/applications/",
-        "/home/phablet/.local/share/applications/",
-    }
-
-    var uappexplorer Application
-    var uappexplorerScope Application
-    var clickstore Application
+var desktopDirectories = []string{
+    "/usr/share/applications/",
+    "/home/phablet/.local/share/applications/",
+}
 
+//Reads the apps & scopes from the desktop files that should be displayed
+func (falcon *Falcon) getDesktopApps() Applications {
     var appList Applications
-    for index := range paths {
-        path := paths[index]
+    for index := range desktopDirectories {
+        path := desktopDirectories[index]
         files, err := ioutil.ReadDir(path)
         if err != nil {
             log.Println(err)
@@ -161,8 +213,16 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
                     var app = Application{}
                     app.Desktop = string(content)
                     app.Uri = "application:///" + f.Name()
+                    app.DesktopId = f.Name()
                     app.IsApp = true
                     app.IsDesktop = false
+                    app.Installed = f.ModTime().Unix()
+
+                    if (index == 0) {
+                        app.Source = "system"
+                    } else {
+                        app.Source = "local"
+                    }
 
                     skip := true
                     nodisplay := false
@@ -188,14 +248,8 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
                     if value, ok := desktopMap["name"]; ok {
                         app.Title = value
 
-                        fullLang := os.Getenv("LANG")
-                        if (fullLang != "") {
-                            split := strings.Split(fullLang, ".")
-                            lang := strings.ToLower(split[0])
-
-                            split = strings.Split(lang, "_")
-                            shortLang := strings.ToLower(split[0])
-
+                        lang, shortLang := falcon.languages()
+                        if (lang != "") {
                             if title, ok := desktopMap[fmt.Sprintf("name[%s]", lang)]; ok {
                                 app.Title = title
                             } else if title, ok := desktopMap[fmt.Sprintf("name[%s]", shortLang)]; ok {
@@ -213,11 +267,11 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
                                 app.Title = translation
                             }
                         }
-
-                        app.Sort = strings.ToLower(app.Title)
                     }
 
                     if value, ok := desktopMap["icon"]; ok {
+                        app.IconName = value
+
                         if (value == "media-memory-sd") { //Special exception for the "External Drives" app
                             app.Icon = "file:///usr/share/icons/Humanity/devices/48/media-memory-sd.svg"
                         } else if (value != "" && value[0:1] == "/") {
@@ -231,11 +285,25 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
                         app.Comment = value
                     }
 
+                    if value, ok := desktopMap["categories"]; ok {
+                        app.Category = falcon.mainCategory(value)
+                    }
+
                     if value, ok := desktopMap["x-ubuntu-application-id"]; ok {
-                        app.Id = falcon.extractId(value)
+                        if (app.Source == "local") {
+                            app.Source = "click"
+                            app.Package = falcon.clickPackage(value)
+
+                            if installed := falcon.clickInstalled(app.Package); installed > 0 {
+                                app.Installed = installed
+                            }
+                        }
+
+                        app.AppId = value
                     } else {
-                        app.Id = falcon.extractId(strings.Replace(f.Name(), ".desktop", "", 1))
+                        app.AppId = strings.Replace(f.Name(), ".desktop", "", 1)
                     }
+                    app.Id = falcon.extractId(app.AppId)
 
                     if value, ok := desktopMap["x-ubuntu-touch"]; (ok && strings.ToLower(value) == "true") {
                         skip = false
@@ -266,53 +334,118 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
                         }
                     }
 
-                    app.Icon = falcon.getIcon(app.Id, app.Icon)
+                    app.SourceIcon = app.Icon
+                    app.Icon = falcon.getAppIcon(app, app.Icon)
 
                     if (!skip && !nodisplay && onlyShowIn == "unity") {
-                        if (strings.Contains(app.Id, "uappexplorer.bhdouglass")) {
-                            uappexplorer = app
-                        } else if (strings.Contains(app.Id, "uappexplorer-scope.bhdouglass")) {
-                            uappexplorerScope = app
-                        } else if (strings.Contains(app.Id, "openstore.openstore-team")) {
-                            clickstore = app
-                        }
-
-                        if (query == "" || strings.Index(strings.ToLower(app.Title), strings.ToLower(query)) >= 0) {
-                            appList = append(appList, app)
-                        }
+                        appList = append(appList, app)
                     }
                 }
             }
         }
     }
 
+    return appList
+}
+
+//Every app, scope and launcher entry Falcon can show, ready to be sorted
+func (falcon *Falcon) installedApps() Applications {
+    var appList Applications
+    appList = append(appList, falcon.getDesktopApps()...)
+    appList = append(appList, falcon.getLibertineApps("")...)
+    appList = append(appList, falcon.getCustomApps("")...)
+
+    var settings Settings
+    falcon.base.Settings(&settings)
+    falcon.newCollator(settings).SetSortKeys(appList)
+
+    return appList
+}
+
+func (falcon *Falcon) appSearch(cannedQuery *scopes.CannedQuery, metadata *scopes.SearchMetadata, reply *scopes.SearchReply) error {
+    var settings Settings
+    falcon.base.Settings(&settings)
+
+    query := cannedQuery.QueryString()
+    department := cannedQuery.DepartmentID()
+
+    var uappexplorer Application
+    var uappexplorerScope Application
+    var clickstore Application
+
+    var appList Applications
+    for _, app := range falcon.getDesktopApps() {
+        if (strings.Contains(app.Id, "uappexplorer.bhdouglass")) {
+            uappexplorer = app
+        } else if (strings.Contains(app.Id, "uappexplorer-scope.bhdouglass")) {
+            uappexplorerScope = app
+        } else if (strings.Contains(app.Id, "openstore.openstore-team")) {
+            clickstore = app
+        }
+
+        if (query == "" || strings.Index(strings.ToLower(app.Title), strings.ToLower(query)) >= 0) {
+            appList = append(appList, app)
+        }
+    }
+
     //Desktop/Libertine Apps
     appList = append(appList, falcon.getLibertineApps(query)...)
 
-    sort.Sort(appList)
+    //User defined launcher entries
+    appList = append(appList, falcon.getCustomApps(query)...)
+
+    falcon.registerDepartments(query, reply)
+    appFilters := falcon.pushFilters(cannedQuery.FilterState(), reply)
+
+    appList = falcon.filterDepartment(appList, department)
+    appList = falcon.filterApps(appList, appFilters)
+    if (department == "scopes") {
+        settings.ShowScopes = true
+    }
+
+    collator := falcon.newCollator(settings)
+    collator.SetSortKeys(appList)
+
+    falcon.sortApps(appList, appFilters)
 
     categories := map[string] *scopes.Category{};
 
-    categoryLayouts := []string{"grid", "carousel", "vertical-journal", "horizontal-list"}
-    cardLayouts := []string{"vertical", "vertical", "horizontal", "vertical"}
-    cardSizes := []string{"small", "medium", "large"}
+    favoritesTemplate := falcon.settingsTemplate(settings.FavoritesLayout, settings.FavoritesSize)
+    appScopeTemplate := falcon.settingsTemplate(settings.AppScopeLayout, settings.AppScopeSize)
 
-    favoritesTemplate := fmt.Sprintf(searchCategoryTemplate, categoryLayouts[settings.FavoritesLayout], cardLayouts[settings.FavoritesLayout], cardSizes[settings.FavoritesSize])
-    appScopeTemplate := fmt.Sprintf(searchCategoryTemplate, categoryLayouts[settings.AppScopeLayout], cardLayouts[settings.AppScopeLayout], cardSizes[settings.AppScopeSize])
+    showFavorites := (department == "" || department == "favorites")
+    if (showFavorites) {
+        categories["favorite"] = falcon.registerCategory(reply, "favorites", "Favorites", favoritesTemplate)
+    }
+
+    falcon.markNewApps(appList, settings.NewApps)
+    showNew := (department == "" || department == "apps")
+    if (showNew) {
+        categories["new"] = falcon.registerCategory(reply, "new", "New", appScopeTemplate)
+    }
 
-    categories["favorite"] = reply.RegisterCategory("favorites", "Favorites", "", favoritesTemplate)
+    falcon.requestUpdateCheck(settings, metadata)
+    falcon.markUpdates(appList)
+    if (showNew) {
+        categories["updates"] = falcon.registerCategory(reply, "updates", "Updates available", appScopeTemplate)
+    }
 
     if (settings.Layout == 0) { //Group by apps & scopes
-        categories["apps"] = reply.RegisterCategory("apps", "Apps", "", appScopeTemplate)
-        categories["desktop"] = reply.RegisterCategory("desktop", "Desktop Apps", "", appScopeTemplate)
-        categories["scopes"] = reply.RegisterCategory("scopes", "Scopes", "", appScopeTemplate)
+        categories["apps"] = falcon.registerCategory(reply, "apps", "Apps", appScopeTemplate)
+        categories["desktop"] = falcon.registerCategory(reply, "desktop", "Desktop Apps", appScopeTemplate)
+        categories["scopes"] = falcon.registerCategory(reply, "scopes", "Scopes", appScopeTemplate)
+    } else if (settings.Layout == 2) { //Group by category
+        for _, group := range falcon.groupList(appList) {
+            categories[group] = falcon.registerCategory(reply, group, falcon.groupTitle(group), appScopeTemplate)
+        }
+    } else if (settings.Layout == 3) { //Group by source
+        for _, group := range falcon.sourceGroupList(appList) {
+            categories[group] = falcon.registerCategory(reply, group, falcon.sourceTitle(group), appScopeTemplate)
+        }
     } else { //Group by first letter
-        //TODO ignore A/An/The
-        //TODO group numbers
-
         charMap := map[string] string{}
         for index := range appList {
-            char := strings.ToUpper(falcon.firstChar(appList[index].Title))
+            char := collator.Bucket(appList[index].Title)
             charMap[char] = char
         }
 
@@ -321,59 +454,81 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
             charList = append(charList, index)
         }
 
-        sort.Strings(charList)
+        collator.SortBuckets(charList)
         for index := range charList {
             char := charList[index]
-            categories[char] = reply.RegisterCategory(char, char, "", appScopeTemplate)
+            categories[char] = falcon.registerCategory(reply, char, char, appScopeTemplate)
         }
     }
 
-    searchTitle := "Search for more apps"
-    if (query != "") {
-        searchTitle = fmt.Sprintf("Search for apps like \"%s\"", query)
-    }
-    storeCategory := reply.RegisterCategory("store", searchTitle, "", fmt.Sprintf(searchCategoryTemplate, "grid", "vertical", "small"))
-
     //Favorites
     for index := range appList {
         app := appList[index]
 
-        if falcon.isFavorite(app.Id) {
-            result := scopes.NewCategorisedResult(categories["favorite"])
-            result.SetURI(app.Uri)
-            result.SetTitle(app.Title)
-            result.SetArt(app.Icon)
-            result.Set("app", app)
-            result.Set("type", "app")
-            result.SetInterceptActivation()
-
+        if showFavorites && falcon.isFavorite(app.Id) {
+            result := falcon.newAppResult(categories["favorite"], app)
             if err := reply.Push(result); err != nil {
                 log.Fatalln(err)
             }
         }
     }
 
+    //Recently installed or updated
+    if (showNew) {
+        for index := range appList {
+            app := appList[index]
+
+            if app.IsNew {
+                result := falcon.newAppResult(categories["new"], app)
+                if err := reply.Push(result); err != nil {
+                    log.Fatalln(err)
+                }
+            }
+        }
+
+        for index := range appList {
+            app := appList[index]
+
+            if app.HasUpdate {
+                result := falcon.newAppResult(categories["updates"], app)
+                if err := reply.Push(result); err != nil {
+                    log.Fatalln(err)
+                }
+            }
+        }
+    }
+
     //Apps first, or all if they are joined
     for index := range appList {
         app := appList[index]
 
-        //See note at next for loop
-        if (settings.Layout == 0 && !app.IsApp) || falcon.isFavorite(app.Id) || (settings.Layout == 0 && settings.SeparateDesktop && app.IsDesktop) {
+        //See note at next for loop, new apps stay in their own category until they are launched or dismissed
+        if (settings.Layout == 0 && !app.IsApp) || (showFavorites && falcon.isFavorite(app.Id)) || (showNew && app.IsNew) || (settings.Layout == 0 && settings.SeparateDesktop && app.IsDesktop) {
             continue
         }
 
         var result *scopes.CategorisedResult
         if (settings.Layout == 0) {
             if (app.IsApp) {
-                result = scopes.NewCategorisedResult(categories["apps"])
+                result = falcon.newAppResult(categories["apps"], app)
             } else if (settings.ShowScopes) {
-                result = scopes.NewCategorisedResult(categories["scopes"])
+                result = falcon.newAppResult(categories["scopes"], app)
             }
         } else {
-            char := strings.ToUpper(falcon.firstChar(app.Title))
-            result = scopes.NewCategorisedResult(categories[char])
+            if (settings.Layout == 2) {
+                result = falcon.newAppResult(categories[falcon.appGroup(app)], app)
+            } else if (settings.Layout == 3) {
+                result = falcon.newAppResult(categories[falcon.sourceGroup(app)], app)
+            } else {
+                char := collator.Bucket(app.Title)
+                result = falcon.newAppResult(categories[char], app)
+            }
 
-            if (app.IsDesktop) {
+            if (settings.Layout == 3) {
+                result.Set("subtitle", falcon.sourceLabel(app))
+            } else if (app.IsCustom) {
+                result.Set("subtitle", "Launcher Entry")
+            } else if (app.IsDesktop) {
                 result.Set("subtitle", "Desktop App")
             } else if (app.IsApp) {
                 result.Set("subtitle", "App")
@@ -382,13 +537,6 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
             }
         }
 
-        result.SetURI(app.Uri)
-        result.SetTitle(app.Title)
-        result.SetArt(app.Icon)
-        result.Set("app", app)
-        result.Set("type", "app")
-        result.SetInterceptActivation()
-
         if err := reply.Push(result); err != nil {
             log.Fatalln(err)
         }
@@ -399,17 +547,11 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
         for index := range appList {
             app := appList[index]
 
-            if (!app.IsDesktop || falcon.isFavorite(app.Id)) {
+            if (!app.IsDesktop || (showFavorites && falcon.isFavorite(app.Id)) || (showNew && app.IsNew)) {
                 continue
             }
 
-            result := scopes.NewCategorisedResult(categories["desktop"])
-            result.SetURI(app.Uri)
-            result.SetTitle(app.Title)
-            result.SetArt(app.Icon)
-            result.Set("app", app)
-            result.Set("type", "app")
-            result.SetInterceptActivation()
+            result := falcon.newAppResult(categories["desktop"], app)
 
             if err := reply.Push(result); err != nil {
                 log.Fatalln(err)
@@ -423,17 +565,11 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
         for index := range appList {
             app := appList[index]
 
-            if (app.IsApp || falcon.isFavorite(app.Id)) {
+            if (app.IsApp || (showFavorites && falcon.isFavorite(app.Id)) || (showNew && app.IsNew)) {
                 continue
             }
 
-            result := scopes.NewCategorisedResult(categories["scopes"])
-            result.SetURI(app.Uri)
-            result.SetTitle(app.Title)
-            result.SetArt(app.Icon)
-            result.Set("app", app)
-            result.Set("type", "app")
-            result.SetInterceptActivation()
+            result := falcon.newAppResult(categories["scopes"], app)
 
             if err := reply.Push(result); err != nil {
                 log.Fatalln(err)
@@ -441,8 +577,17 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
         }
     }
 
-    //TODO make a setting for this
-    //TODO give an option to search online immediately instead of going to another scope/app
+    //The store, launcher entry and icon pack results only belong on the main page
+    if (department != "") {
+        return nil
+    }
+
+    searchTitle := "Search for more apps"
+    if (query != "") {
+        searchTitle = fmt.Sprintf("Search for apps like \"%s\"", query)
+    }
+    storeCategory := falcon.registerCategory(reply, "store", searchTitle, NewCategoryTemplate("grid", "vertical", "small"))
+
     var store Application
     if (uappexplorerScope.Id != "") {
         store = uappexplorerScope
@@ -465,12 +610,28 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
     }
 
     if (store.Id != "") {
-        result := scopes.NewCategorisedResult(storeCategory)
-        result.SetURI(store.Uri)
-        result.SetTitle(store.Title)
-        result.SetArt(store.Icon)
-        result.Set("app", store)
-        result.Set("type", "app")
+        result := falcon.newAppResult(storeCategory, store)
+
+        if err := reply.Push(result); err != nil {
+            log.Fatalln(err)
+        }
+    }
+
+    //Custom launcher entry result
+    if (query == "" || strings.Contains(query, ":")) {
+        customCategory := falcon.registerCategory(reply, "custom-entries", "Launcher Entries", NewCategoryTemplate("grid", "vertical", "small"))
+
+        customTitle := "Add a launcher entry"
+        if (query != "") {
+            customTitle = fmt.Sprintf("Add \"%s\" as a launcher entry", query)
+        }
+
+        result := scopes.NewCategorisedResult(customCategory)
+        result.SetURI("custom-entry")
+        result.SetTitle(customTitle)
+        result.SetArt(falcon.getIcon("add-launcher-entry", "file:///usr/share/icons/suru/actions/scalable/add.svg"))
+        result.Set("type", "custom-entry")
+        result.Set("uri", query)
         result.SetInterceptActivation()
 
         if err := reply.Push(result); err != nil {
@@ -479,10 +640,10 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
     }
 
     //Icon pack result
-    iconPackCategory := reply.RegisterCategory("icon-packs", "Icon Packs", "", fmt.Sprintf(searchCategoryTemplate, "grid", "vertical", "small"))
+    iconPackCategory := falcon.registerCategory(reply, "icon-packs", "Icon Packs", NewCategoryTemplate("grid", "vertical", "small"))
 
     result := scopes.NewCategorisedResult(iconPackCategory)
-    result.SetURI("scope://falcon.bhdouglass_falcon?q=icon-packs")
+    result.SetURI(scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "icon-packs").ToURI())
     result.SetTitle("Find Icon Packs")
     result.SetArt(falcon.getIcon("find-icon-packs", falcon.base.ScopeDirectory() + "/icon-packs.svg"))
     result.Set("type", "icon-packs")
@@ -492,9 +653,30 @@ func (falcon *Falcon) appSearch(query string, reply *scopes.SearchReply) error {
         log.Fatalln(err)
     }
 
+    //The local results are already shown while waiting for the store
+    falcon.storeSearch(query, settings, metadata, reply)
+
     return nil
 }
 
+func (falcon *Falcon) newAppResult(category *scopes.Category, app Application) *scopes.CategorisedResult {
+    result := scopes.NewCategorisedResult(category)
+    result.SetURI(app.Uri)
+    result.SetTitle(app.Title)
+    result.SetArt(app.Icon)
+    result.Set("app", app)
+    result.Set("type", "app")
+    result.SetInterceptActivation()
+
+    if app.HasUpdate {
+        result.Set("emblem", falcon.base.ScopeDirectory() + "/update.svg")
+    } else if app.IsNew {
+        result.Set("emblem", falcon.base.ScopeDirectory() + "/new.svg")
+    }
+
+    return result
+}
+
 func (falcon *Falcon) appPerformAction(result *scopes.Result, metadata *scopes.ActionMetadata, widgetId, actionId string) *scopes.ActivationResponse {
     var resp *scopes.ActivationResponse
 
@@ -520,6 +702,8 @@ func (falcon *Falcon) appPerformAction(result *scopes.Result, metadata *scopes.A
         query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
         resp = scopes.NewActivationResponseForQuery(query)
     } else { //action is launch
+        falcon.recordLaunch(app.Id)
+
         if app.IsApp {
             resp = scopes.NewActivationResponse(scopes.ActivationNotHandled)
         } else {
@@ -538,6 +722,8 @@ func (falcon *Falcon) appActivate(result *scopes.Result, metadata *scopes.Action
         log.Println(err)
     }
 
+    falcon.recordLaunch(app.Id)
+
     if app.IsApp {
         //Let the uri handler open the app
         resp = scopes.NewActivationResponse(scopes.ActivationNotHandled)
@@ -0,0 +1,146 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "sort"
+    "strings"
+    "time"
+)
+
+type ClickManifest struct {
+    Name         string                            `json:"name"`
+    Title        string                            `json:"title"`
+    Version      string                            `json:"version"`
+    Maintainer   string                            `json:"maintainer"`
+    Framework    string                            `json:"framework"`
+    Architecture interface{}                       `json:"architecture"`
+    Hooks        map[string]map[string]interface{} `json:"hooks"`
+}
+
+type AppArmorProfile struct {
+    Template     string   `json:"template"`
+    PolicyGroups []string `json:"policy_groups"`
+}
+
+func (falcon *Falcon) clickPath(pkg string) string {
+    return fmt.Sprintf("%s%s/current/", clickDirectory, pkg)
+}
+
+func (falcon *Falcon) readClickManifest(pkg string) (ClickManifest, error) {
+    var manifest ClickManifest
+
+    content, err := ioutil.ReadFile(falcon.clickPath(pkg) + "manifest.json")
+    if err == nil {
+        err = json.Unmarshal(content, &manifest)
+    }
+
+    return manifest, err
+}
+
+//The app name is the part of the app id after the package name (ie: falcon in falcon.bhdouglass_falcon)
+func (falcon *Falcon) clickAppName(appId string) string {
+    split := strings.Split(appId, "_")
+    if len(split) < 2 {
+        return ""
+    }
+
+    return split[1]
+}
+
+func (falcon *Falcon) readAppArmorProfile(pkg string, manifest ClickManifest, appName string) (AppArmorProfile, error) {
+    var profile AppArmorProfile
+
+    file, ok := manifest.Hooks[appName]["apparmor"].(string)
+    if !ok || file == "" {
+        return profile, fmt.Errorf("No apparmor hook for %s in %s", appName, pkg)
+    }
+
+    content, err := ioutil.ReadFile(falcon.clickPath(pkg) + file)
+    if err == nil {
+        err = json.Unmarshal(content, &profile)
+    }
+
+    return profile, err
+}
+
+func (manifest ClickManifest) architecture() string {
+    architecture := ""
+
+    if value, ok := manifest.Architecture.(string); ok {
+        architecture = value
+    } else if values, ok := manifest.Architecture.([]interface{}); ok {
+        var list []string
+        for _, value := range values {
+            list = append(list, fmt.Sprintf("%v", value))
+        }
+
+        architecture = strings.Join(list, ", ")
+    }
+
+    return architecture
+}
+
+func (falcon *Falcon) clickDetailsWidgets(app Application) []scopes.PreviewWidget {
+    var widgets []scopes.PreviewWidget
+
+    manifest, err := falcon.readClickManifest(app.Package)
+    if err != nil {
+        log.Printf("Error while reading the manifest of %s:", app.Package)
+        log.Println(err)
+
+        return widgets
+    }
+
+    var details []string
+    details = append(details, fmt.Sprintf("<b>Version:</b> %s", manifest.Version))
+    details = append(details, fmt.Sprintf("<b>Maintainer:</b> %s", manifest.Maintainer))
+    details = append(details, fmt.Sprintf("<b>Framework:</b> %s", manifest.Framework))
+    details = append(details, fmt.Sprintf("<b>Architecture:</b> %s", manifest.architecture()))
+
+    if installed := falcon.clickInstalled(app.Package); installed > 0 {
+        details = append(details, fmt.Sprintf("<b>Installed:</b> %s", time.Unix(installed, 0).Format("2006-01-02 15:04")))
+    }
+
+    //The hooks are named as in the manifest, so the lowercased id can't be used
+    appName := falcon.clickAppName(app.AppId)
+    if hooks, ok := manifest.Hooks[appName]; ok {
+        var hookList []string
+        for hook := range hooks {
+            hookList = append(hookList, hook)
+        }
+
+        sort.Strings(hookList)
+        details = append(details, fmt.Sprintf("<b>Hooks:</b> %s", strings.Join(hookList, ", ")))
+    }
+
+    detailsWidget := scopes.NewPreviewWidget("click-details", "text")
+    detailsWidget.AddAttributeValue("title", "Package")
+    detailsWidget.AddAttributeValue("text", strings.Join(details, "<br>"))
+    widgets = append(widgets, detailsWidget)
+
+    profile, err := falcon.readAppArmorProfile(app.Package, manifest, appName)
+    if err != nil {
+        log.Println(err)
+    } else {
+        policyGroups := "None"
+        if len(profile.PolicyGroups) > 0 {
+            policyGroups = strings.Join(profile.PolicyGroups, ", ")
+        }
+
+        permissions := fmt.Sprintf("<b>Policy groups:</b> %s", policyGroups)
+        if profile.Template != "" {
+            permissions = fmt.Sprintf("<b>Template:</b> %s<br>%s", profile.Template, permissions)
+        }
+
+        permissionsWidget := scopes.NewPreviewWidget("click-permissions", "text")
+        permissionsWidget.AddAttributeValue("title", "Permissions")
+        permissionsWidget.AddAttributeValue("text", permissions)
+        widgets = append(widgets, permissionsWidget)
+    }
+
+    return widgets
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+    "os"
+    "sort"
+    "strings"
+    "unicode"
+)
+
+//Leading words that are skipped when sorting and grouping, by language
+var defaultArticles = map[string][]string{
+    "en": []string{"the", "a", "an"},
+    "de": []string{"der", "die", "das", "ein", "eine"},
+    "es": []string{"el", "la", "los", "las", "un", "una"},
+    "fr": []string{"le", "la", "les", "l'", "un", "une"},
+    "it": []string{"il", "lo", "la", "i", "gli", "le", "l'", "un", "una"},
+    "nl": []string{"de", "het", "een"},
+    "pt": []string{"o", "a", "os", "as", "um", "uma"},
+}
+
+//Letters that are not folded into their base letter, mapped to the key they sort as
+var localeLetters = map[string]map[rune]string{
+    "sv": map[rune]string{'å': "z~1", 'ä': "z~2", 'ö': "z~3"},
+    "fi": map[rune]string{'å': "z~1", 'ä': "z~2", 'ö': "z~3"},
+    "da": map[rune]string{'æ': "z~1", 'ø': "z~2", 'å': "z~3"},
+    "nb": map[rune]string{'æ': "z~1", 'ø': "z~2", 'å': "z~3"},
+    "nn": map[rune]string{'æ': "z~1", 'ø': "z~2", 'å': "z~3"},
+    "es": map[rune]string{'ñ': "n~1"},
+    "pl": map[rune]string{'ą': "a~1", 'ć': "c~1", 'ę': "e~1", 'ł': "l~1", 'ń': "n~1", 'ó': "o~1", 'ś': "s~1", 'ź': "z~1", 'ż': "z~2"},
+    "cs": map[rune]string{'č': "c~1", 'ř': "r~1", 'š': "s~1", 'ž': "z~1"},
+    "tr": map[rune]string{'ç': "c~1", 'ğ': "g~1", 'ı': "h~1", 'ö': "o~1", 'ş': "s~1", 'ü': "u~1"},
+}
+
+var foldedLetters = map[rune]string{}
+
+func init() {
+    folds := map[string]string{
+        "a": "àáâãäåāăą",
+        "ae": "æ",
+        "c": "çćčĉċ",
+        "d": "ďđ",
+        "e": "èéêëēĕėęě",
+        "g": "ĝğġģ",
+        "h": "ĥħ",
+        "i": "ìíîïĩīĭįı",
+        "j": "ĵ",
+        "k": "ķ",
+        "l": "ĺļľŀł",
+        "n": "ñńņňŉ",
+        "o": "òóôõöøōŏő",
+        "oe": "œ",
+        "r": "ŕŗř",
+        "s": "śŝşš",
+        "ss": "ß",
+        "t": "ţťŧ",
+        "u": "ùúûüũūŭůűų",
+        "w": "ŵ",
+        "y": "ýÿŷ",
+        "z": "źżž",
+    }
+
+    for base, letters := range folds {
+        for _, letter := range letters {
+            foldedLetters[letter] = base
+        }
+    }
+}
+
+type Collator struct {
+    Articles []string
+    Letters  map[rune]string
+}
+
+//Returns the full (ie: en_us) and short (ie: en) language from the environment
+func (falcon *Falcon) languages() (string, string) {
+    lang := ""
+    shortLang := ""
+
+    fullLang := os.Getenv("LANG")
+    if (fullLang != "") {
+        split := strings.Split(fullLang, ".")
+        lang = strings.ToLower(split[0])
+
+        split = strings.Split(lang, "_")
+        shortLang = strings.ToLower(split[0])
+    }
+
+    return lang, shortLang
+}
+
+//Reads the articles setting, either one list for every language (ie: the, a) or lists per language (ie: en: the, a; de: der, die)
+func parseArticles(setting string, shortLang string) ([]string, bool) {
+    var articles []string
+    found := false
+
+    for _, entry := range strings.Split(setting, ";") {
+        if strings.TrimSpace(entry) == "" {
+            continue
+        }
+
+        //An apostrophe article (ie: l') never contains a colon, so anything before one is a language
+        if pos := strings.Index(entry, ":"); pos >= 0 {
+            if strings.ToLower(strings.TrimSpace(entry[0:pos])) != shortLang {
+                continue
+            }
+
+            entry = entry[(pos + 1):]
+        }
+
+        found = true
+        for _, article := range strings.Split(entry, ",") {
+            article = strings.ToLower(strings.TrimSpace(article))
+            if article != "" {
+                articles = append(articles, article)
+            }
+        }
+    }
+
+    return articles, found
+}
+
+func (falcon *Falcon) newCollator(settings Settings) Collator {
+    _, shortLang := falcon.languages()
+
+    var collator Collator
+    collator.Letters = localeLetters[shortLang]
+
+    if articles, ok := parseArticles(settings.Articles, shortLang); ok {
+        collator.Articles = articles
+    } else {
+        collator.Articles = defaultArticles[shortLang]
+    }
+
+    return collator
+}
+
+//Gives every app the key it is sorted by, sort.Sort(appList) expects this to be set
+func (collator Collator) SetSortKeys(appList Applications) {
+    for index := range appList {
+        appList[index].Sort = collator.Key(appList[index].Title)
+    }
+}
+
+func (collator Collator) stripArticle(title string) string {
+    lower := strings.ToLower(strings.TrimSpace(title))
+
+    for _, article := range collator.Articles {
+        prefix := article
+        if !strings.HasSuffix(article, "'") {
+            prefix = article + " "
+        }
+
+        if strings.HasPrefix(lower, prefix) && strings.TrimSpace(lower[len(prefix):]) != "" {
+            return strings.TrimSpace(lower[len(prefix):])
+        }
+    }
+
+    return lower
+}
+
+func (collator Collator) foldRune(r rune) string {
+    if key, ok := collator.Letters[r]; ok {
+        return key
+    } else if folded, ok := foldedLetters[r]; ok {
+        return folded
+    }
+
+    return string(r)
+}
+
+//Key returns a string that sorts the title in the order expected by the locale
+func (collator Collator) Key(title string) string {
+    var key []string
+    for _, r := range collator.stripArticle(title) {
+        key = append(key, collator.foldRune(r))
+    }
+
+    return strings.Join(key, "")
+}
+
+//Bucket returns the index letter the title is grouped under, or "#" for digits and symbols
+func (collator Collator) Bucket(title string) string {
+    for _, r := range collator.stripArticle(title) {
+        if !unicode.IsLetter(r) {
+            return "#"
+        }
+
+        if _, ok := collator.Letters[r]; ok {
+            return strings.ToUpper(string(r))
+        }
+
+        return strings.ToUpper(string([]rune(collator.foldRune(r))[0]))
+    }
+
+    return "#"
+}
+
+type bucketSorter struct {
+    buckets  []string
+    collator Collator
+}
+
+func (sorter bucketSorter) Len() int {
+    return len(sorter.buckets)
+}
+
+func (sorter bucketSorter) Less(a, b int) bool {
+    if sorter.buckets[a] == "#" || sorter.buckets[b] == "#" {
+        return sorter.buckets[a] == "#" && sorter.buckets[b] != "#"
+    }
+
+    return sorter.collator.Key(sorter.buckets[a]) < sorter.collator.Key(sorter.buckets[b])
+}
+
+func (sorter bucketSorter) Swap(a, b int) {
+    sorter.buckets[a], sorter.buckets[b] = sorter.buckets[b], sorter.buckets[a]
+}
+
+func (collator Collator) SortBuckets(buckets []string) {
+    sort.Sort(bucketSorter{buckets: buckets, collator: collator})
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestParseArticles(t *testing.T) {
+    tests := []struct {
+        setting  string
+        lang     string
+        articles []string
+        found    bool
+    }{
+        {"", "en", nil, false},
+        {"The, A", "de", []string{"the", "a"}, true},
+        {"en: the, a; de: der, die", "de", []string{"der", "die"}, true},
+        {"en: the, a; de: der, die", "fr", nil, false},
+        {"fr: le, l'; the", "fr", []string{"le", "l'", "the"}, true},
+    }
+
+    for _, test := range tests {
+        articles, found := parseArticles(test.setting, test.lang)
+        if found != test.found || !reflect.DeepEqual(articles, test.articles) {
+            t.Errorf("parseArticles(%q, %q) = %v, %v, want %v, %v", test.setting, test.lang, articles, found, test.articles, test.found)
+        }
+    }
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "strings"
+    "sync"
+    "time"
+)
+
+func (falcon *Falcon) customEntryToApp(entry CustomEntry) Application {
+    var app Application
+    app.Id = entry.Id
+    app.Title = entry.Title
+    app.Comment = entry.Uri
+    app.Uri = entry.Uri
+    app.IsApp = true
+    app.IsCustom = true
+    app.Source = "custom"
+
+    icon := "file:///usr/share/icons/suru/apps/128/placeholder-app-icon.png"
+    if entry.Icon != "" {
+        if entry.Icon[0:1] == "/" {
+            icon = "file://" + entry.Icon
+        } else {
+            icon = entry.Icon
+        }
+    }
+    app.SourceIcon = icon
+    app.Icon = falcon.getIcon(app.Id, icon)
+
+    return app
+}
+
+func (falcon *Falcon) getCustomApps(query string) Applications {
+    var appList Applications
+
+    for _, entry := range falcon.customEntries {
+        app := falcon.customEntryToApp(entry)
+
+        if (query == "" || strings.Index(strings.ToLower(app.Title), strings.ToLower(query)) >= 0) {
+            appList = append(appList, app)
+        }
+    }
+
+    return appList
+}
+
+func (falcon *Falcon) findCustomEntry(id string) (CustomEntry, bool) {
+    for _, entry := range falcon.customEntries {
+        if entry.Id == id {
+            return entry, true
+        }
+    }
+
+    return CustomEntry{}, false
+}
+
+//The entries being edited, kept per preview so one preview never sees the changes of another
+type CustomDrafts struct {
+    sync.Mutex
+    drafts map[string]CustomEntry
+}
+
+func (drafts *CustomDrafts) Get(key string) CustomEntry {
+    drafts.Lock()
+    defer drafts.Unlock()
+
+    return drafts.drafts[key]
+}
+
+func (drafts *CustomDrafts) Set(key string, draft CustomEntry) {
+    drafts.Lock()
+    defer drafts.Unlock()
+
+    if drafts.drafts == nil {
+        drafts.drafts = map[string]CustomEntry{}
+    }
+
+    drafts.drafts[key] = draft
+}
+
+func (drafts *CustomDrafts) Forget(key string) {
+    drafts.Lock()
+    defer drafts.Unlock()
+
+    delete(drafts.drafts, key)
+}
+
+//Existing entries are edited from their app result, new ones from the "Add a launcher entry" result of a query
+func customDraftKey(result *scopes.Result) string {
+    var app Application
+    if err := result.Get("app", &app); err == nil && app.Id != "" {
+        return "edit:" + app.Id
+    }
+
+    var uri string
+    if err := result.Get("uri", &uri); err != nil {
+        log.Println(err)
+    }
+
+    return "new:" + uri
+}
+
+func (falcon *Falcon) customEntryPreview(result *scopes.Result, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply) error {
+    var state PreviewState
+    if err := metadata.ScopeData(&state); err != nil {
+        log.Println(err)
+    }
+
+    //A fresh preview starts a new draft, the comment inputs come back here with the editing flag set
+    key := customDraftKey(result)
+    if !state.Editing {
+        var uri string
+        if err := result.Get("uri", &uri); err != nil {
+            log.Println(err)
+        }
+
+        falcon.customDrafts.Set(key, CustomEntry{Uri: uri})
+    }
+
+    return falcon.customEditorPreview(falcon.customDrafts.Get(key), metadata, reply)
+}
+
+func (falcon *Falcon) customEditorPreview(draft CustomEntry, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply) error {
+
+    titleWidget := scopes.NewPreviewWidget("title", "header")
+    if draft.Id == "" {
+        titleWidget.AddAttributeValue("title", "New launcher entry")
+    } else {
+        titleWidget.AddAttributeValue("title", "Edit launcher entry")
+    }
+    titleWidget.AddAttributeValue("subtitle", "Launch any uri, like a website, a settings page or a phone number")
+
+    detailsWidget := scopes.NewPreviewWidget("details", "text")
+    detailsWidget.AddAttributeValue("text", fmt.Sprintf("<b>Title:</b> %s<br><b>Uri:</b> %s<br><b>Icon:</b> %s", draft.Title, draft.Uri, draft.Icon))
+
+    titleInput := scopes.NewPreviewWidget("custom:title", "comment-input")
+    titleInput.AddAttributeValue("submit-label", "Set title")
+
+    uriInput := scopes.NewPreviewWidget("custom:uri", "comment-input")
+    uriInput.AddAttributeValue("submit-label", "Set uri")
+
+    iconInput := scopes.NewPreviewWidget("custom:icon", "comment-input")
+    iconInput.AddAttributeValue("submit-label", "Set icon path")
+
+    var buttons []ActionInfo
+    buttons = append(buttons, ActionInfo{Id: "custom:save", Label: "Save"})
+    buttons = append(buttons, ActionInfo{Id: "custom:cancel", Label: "Cancel"})
+
+    actionsWidget := scopes.NewPreviewWidget("actions", "actions")
+    actionsWidget.AddAttributeValue("actions", buttons)
+
+    return falcon.pushPreview(metadata, reply, PreviewColumns{
+        Primary: []scopes.PreviewWidget{titleWidget, detailsWidget, actionsWidget},
+        Details: []scopes.PreviewWidget{titleInput, uriInput, iconInput},
+    })
+}
+
+func (falcon *Falcon) customPerformAction(result *scopes.Result, metadata *scopes.ActionMetadata, widgetId, actionId string) *scopes.ActivationResponse {
+    var resp *scopes.ActivationResponse
+    key := customDraftKey(result)
+
+    if actionId == "commented" {
+        var input map[string]interface{}
+        if err := metadata.ScopeData(&input); err != nil {
+            log.Println(err)
+        }
+
+        value := ""
+        if comment, ok := input["comment"].(string); ok {
+            value = strings.TrimSpace(comment)
+        }
+
+        draft := falcon.customDrafts.Get(key)
+        if widgetId == "custom:title" {
+            draft.Title = value
+        } else if widgetId == "custom:uri" {
+            draft.Uri = value
+        } else if widgetId == "custom:icon" {
+            draft.Icon = value
+        }
+        falcon.customDrafts.Set(key, draft)
+
+        resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+        resp.SetScopeData(PreviewState{Editing: true})
+    } else if actionId == "custom:edit" {
+        var app Application
+        if err := result.Get("app", &app); err != nil {
+            log.Println(err)
+        }
+
+        if entry, ok := falcon.findCustomEntry(app.Id); ok {
+            falcon.customDrafts.Set(key, entry)
+        }
+
+        resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+        resp.SetScopeData(PreviewState{Editing: true})
+    } else if actionId == "custom:save" {
+        draft := falcon.customDrafts.Get(key)
+        if draft.Title == "" || draft.Uri == "" {
+            log.Println("Not saving launcher entry without a title and uri")
+
+            resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+            resp.SetScopeData(PreviewState{Editing: true})
+        } else {
+            falcon.saveCustomEntry(draft)
+            falcon.customDrafts.Forget(key)
+
+            //redirect to blank search
+            query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
+            resp = scopes.NewActivationResponseForQuery(query)
+        }
+    } else if actionId == "custom:delete" {
+        var app Application
+        if err := result.Get("app", &app); err != nil {
+            log.Println(err)
+        }
+
+        falcon.deleteCustomEntry(app.Id)
+
+        //redirect to blank search
+        query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
+        resp = scopes.NewActivationResponseForQuery(query)
+    } else { //action is cancel
+        falcon.customDrafts.Forget(key)
+
+        //redirect to blank search
+        query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
+        resp = scopes.NewActivationResponseForQuery(query)
+    }
+
+    return resp
+}
+
+func (falcon *Falcon) saveCustomEntry(entry CustomEntry) {
+    if entry.Id == "" {
+        entry.Id = fmt.Sprintf("falcon-custom-%d", time.Now().UnixNano())
+        falcon.customEntries = append(falcon.customEntries, entry)
+    } else {
+        for index := range falcon.customEntries {
+            if falcon.customEntries[index].Id == entry.Id {
+                falcon.customEntries[index] = entry
+            }
+        }
+    }
+
+    falcon.saveCustomEntries()
+}
+
+func (falcon *Falcon) deleteCustomEntry(id string) {
+    var newEntries []CustomEntry
+
+    for _, entry := range falcon.customEntries {
+        if entry.Id != id {
+            newEntries = append(newEntries, entry)
+        }
+    }
+
+    falcon.customEntries = newEntries
+    falcon.saveCustomEntries()
+
+    if falcon.isFavorite(id) {
+        falcon.unfavorite(id)
+    }
+}
+
+func (falcon *Falcon) saveCustomEntries() {
+    if err := writeJsonFile(falcon.customFile, falcon.customEntries); err != nil {
+        log.Println(err)
+    }
+}
+
+func (falcon *Falcon) loadCustomEntries() {
+    content, err := ioutil.ReadFile(falcon.customFile)
+    if err != nil {
+        log.Println(err)
+    } else if err := json.Unmarshal(content, &falcon.customEntries); err != nil {
+        log.Println(err)
+    }
+}
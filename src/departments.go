@@ -0,0 +1,80 @@
+package main
+
+import (
+    "fmt"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "strings"
+)
+
+func (falcon *Falcon) newDepartment(id string, query string, label string) *scopes.Department {
+    department, err := scopes.NewDepartment(id, scopes.NewCannedQuery("falcon.bhdouglass_falcon", query, id), label)
+    if err != nil {
+        log.Println(err)
+    }
+
+    return department
+}
+
+func (falcon *Falcon) registerDepartments(query string, reply *scopes.SearchReply) {
+    root := falcon.newDepartment("", query, "All")
+    if root == nil {
+        return
+    }
+
+    children := []*scopes.Department{
+        falcon.newDepartment("favorites", query, "Favorites"),
+        falcon.newDepartment("apps", query, "Apps"),
+    }
+
+    desktop := falcon.newDepartment("desktop", query, "Desktop Apps")
+    if desktop != nil {
+        for _, container := range falcon.containers {
+            if child := falcon.newDepartment(fmt.Sprintf("desktop:%s", container.Id), query, container.Name); child != nil {
+                desktop.AddSubdepartment(child)
+            }
+        }
+    }
+    children = append(children, desktop)
+
+    children = append(children, falcon.newDepartment("scopes", query, "Scopes"))
+    children = append(children, falcon.newDepartment("icon-packs", "", "Icon Packs"))
+
+    for _, child := range children {
+        if child != nil {
+            root.AddSubdepartment(child)
+        }
+    }
+
+    reply.RegisterDepartments(root)
+}
+
+func (falcon *Falcon) inDepartment(app Application, department string) bool {
+    inDepartment := true
+
+    if department == "favorites" {
+        inDepartment = falcon.isFavorite(app.Id)
+    } else if department == "apps" {
+        inDepartment = (app.IsApp && !app.IsDesktop)
+    } else if department == "desktop" {
+        inDepartment = app.IsDesktop
+    } else if strings.HasPrefix(department, "desktop:") {
+        inDepartment = (app.IsDesktop && app.Container == strings.TrimPrefix(department, "desktop:"))
+    } else if department == "scopes" {
+        inDepartment = !app.IsApp
+    }
+
+    return inDepartment
+}
+
+func (falcon *Falcon) filterDepartment(appList Applications, department string) Applications {
+    var filtered Applications
+
+    for _, app := range appList {
+        if falcon.inDepartment(app, department) {
+            filtered = append(filtered, app)
+        }
+    }
+
+    return filtered
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+    "fmt"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+)
+
+//How long a calculated size is trusted before it gets calculated again
+const diskUsageExpiry = 10 * 60
+
+//How many packages are measured at the same time
+const diskUsageWorkers = 2
+
+type AppDirectory struct {
+    Name string
+    Path string
+}
+
+type DiskUsage struct {
+    Install int64
+    Data    int64
+    Cache   int64
+    Config  int64
+    Updated int64
+}
+
+type DiskUsageCache struct {
+    sync.Mutex
+    usage   map[string]DiskUsage
+    waiting map[string][]chan DiskUsage
+    pending []string
+    workers int
+}
+
+func (usage DiskUsage) Total() int64 {
+    return usage.Install + usage.Data + usage.Cache + usage.Config
+}
+
+func (falcon *Falcon) homeDirectory() string {
+    home := os.Getenv("HOME")
+    if home == "" {
+        home = "/home/phablet"
+    }
+
+    return home
+}
+
+//The XDG directories that belong to a click package
+func (falcon *Falcon) appDirectories(pkg string) []AppDirectory {
+    home := falcon.homeDirectory()
+
+    return []AppDirectory{
+        AppDirectory{Name: "data", Path: filepath.Join(home, ".local", "share", pkg)},
+        AppDirectory{Name: "cache", Path: filepath.Join(home, ".cache", pkg)},
+        AppDirectory{Name: "config", Path: filepath.Join(home, ".config", pkg)},
+    }
+}
+
+func directorySize(path string) int64 {
+    var size int64
+
+    if resolved, err := filepath.EvalSymlinks(path); err == nil {
+        path = resolved
+    }
+
+    filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+        if err != nil {
+            //Missing or unreadable files just don't count
+            return nil
+        }
+
+        if info.Mode().IsRegular() {
+            size += info.Size()
+        }
+
+        return nil
+    })
+
+    return size
+}
+
+func formatSize(size int64) string {
+    units := []string{"B", "KB", "MB", "GB"}
+
+    value := float64(size)
+    unit := 0
+    for value >= 1024 && unit < len(units) - 1 {
+        value = value / 1024
+        unit++
+    }
+
+    if unit == 0 {
+        return fmt.Sprintf("%d %s", size, units[unit])
+    }
+
+    return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+func (falcon *Falcon) calculateDiskUsage(pkg string) DiskUsage {
+    var usage DiskUsage
+    usage.Install = directorySize(falcon.clickPath(pkg))
+
+    for _, directory := range falcon.appDirectories(pkg) {
+        size := directorySize(directory.Path)
+
+        if directory.Name == "data" {
+            usage.Data = size
+        } else if directory.Name == "cache" {
+            usage.Cache = size
+        } else if directory.Name == "config" {
+            usage.Config = size
+        }
+    }
+
+    usage.Updated = time.Now().Unix()
+
+    return usage
+}
+
+//Returns the cached disk usage of a package, if it is still fresh
+func (falcon *Falcon) cachedDiskUsage(pkg string) (DiskUsage, bool) {
+    falcon.diskUsage.Lock()
+    defer falcon.diskUsage.Unlock()
+
+    usage, ok := falcon.diskUsage.usage[pkg]
+    if ok && usage.Updated < time.Now().Unix() - diskUsageExpiry {
+        ok = false
+    }
+
+    return usage, ok
+}
+
+func (falcon *Falcon) forgetDiskUsage(pkg string) {
+    falcon.diskUsage.Lock()
+    defer falcon.diskUsage.Unlock()
+
+    delete(falcon.diskUsage.usage, pkg)
+}
+
+//Calculates the disk usage of a package in the background, the channel receives the result when it is ready
+func (falcon *Falcon) requestDiskUsage(pkg string) <-chan DiskUsage {
+    done := make(chan DiskUsage, 1)
+
+    if usage, ok := falcon.cachedDiskUsage(pkg); ok {
+        done <- usage
+        return done
+    }
+
+    falcon.diskUsage.Lock()
+    defer falcon.diskUsage.Unlock()
+
+    if falcon.diskUsage.usage == nil {
+        falcon.diskUsage.usage = map[string]DiskUsage{}
+        falcon.diskUsage.waiting = map[string][]chan DiskUsage{}
+    }
+
+    waiting, queued := falcon.diskUsage.waiting[pkg]
+    falcon.diskUsage.waiting[pkg] = append(waiting, done)
+
+    if !queued {
+        falcon.diskUsage.pending = append(falcon.diskUsage.pending, pkg)
+
+        if falcon.diskUsage.workers < diskUsageWorkers {
+            falcon.diskUsage.workers++
+            go falcon.diskUsageWorker()
+        }
+    }
+
+    return done
+}
+
+//Measures the pending packages one at a time, the worker stops once nothing is left
+func (falcon *Falcon) diskUsageWorker() {
+    for {
+        falcon.diskUsage.Lock()
+        if len(falcon.diskUsage.pending) == 0 {
+            falcon.diskUsage.workers--
+            falcon.diskUsage.Unlock()
+            return
+        }
+
+        pkg := falcon.diskUsage.pending[0]
+        falcon.diskUsage.pending = falcon.diskUsage.pending[1:]
+        falcon.diskUsage.Unlock()
+
+        usage := falcon.calculateDiskUsage(pkg)
+
+        falcon.diskUsage.Lock()
+        falcon.diskUsage.usage[pkg] = usage
+        for _, waiter := range falcon.diskUsage.waiting[pkg] {
+            waiter <- usage
+        }
+        delete(falcon.diskUsage.waiting, pkg)
+        falcon.diskUsage.Unlock()
+    }
+}
+
+//Looks up the cached sizes of the apps, the missing ones are left out and calculated in the background for the next search
+func (falcon *Falcon) diskUsageSizes(appList Applications) map[string]int64 {
+    sizes := map[string]int64{}
+
+    for _, app := range appList {
+        if app.Package == "" {
+            continue
+        }
+
+        if usage, ok := falcon.cachedDiskUsage(app.Package); ok {
+            sizes[app.Package] = usage.Total()
+        } else {
+            falcon.requestDiskUsage(app.Package)
+        }
+    }
+
+    return sizes
+}
+
+func (falcon *Falcon) diskUsageText(usage DiskUsage) string {
+    var lines []string
+    lines = append(lines, fmt.Sprintf("<b>Total:</b> %s", formatSize(usage.Total())))
+    lines = append(lines, fmt.Sprintf("<b>App:</b> %s", formatSize(usage.Install)))
+    lines = append(lines, fmt.Sprintf("<b>Data:</b> %s", formatSize(usage.Data)))
+    lines = append(lines, fmt.Sprintf("<b>Cache:</b> %s", formatSize(usage.Cache)))
+    lines = append(lines, fmt.Sprintf("<b>Config:</b> %s", formatSize(usage.Config)))
+
+    return strings.Join(lines, "<br>")
+}
+
+func (falcon *Falcon) diskUsageWidget(app Application) scopes.PreviewWidget {
+    widget := scopes.NewPreviewWidget("disk-usage", "text")
+    widget.AddAttributeValue("title", "Storage")
+
+    if usage, ok := falcon.cachedDiskUsage(app.Package); ok {
+        widget.AddAttributeValue("text", falcon.diskUsageText(usage))
+    } else {
+        widget.AddAttributeMapping("text", "disk_usage")
+    }
+
+    return widget
+}
+
+//Fills in the storage widget once the sizes are calculated
+func (falcon *Falcon) pushDiskUsage(app Application, reply *scopes.PreviewReply, cancelled <-chan bool) {
+    if _, ok := falcon.cachedDiskUsage(app.Package); ok {
+        return
+    }
+
+    if err := reply.PushAttr("disk_usage", "Calculating..."); err != nil {
+        log.Println(err)
+    }
+
+    select {
+    case usage := <-falcon.requestDiskUsage(app.Package):
+        if err := reply.PushAttr("disk_usage", falcon.diskUsageText(usage)); err != nil {
+            log.Println(err)
+        }
+    case <-cancelled:
+    case <-time.After(30 * time.Second):
+        log.Printf("Timed out calculating the size of %s", app.Package)
+    }
+}
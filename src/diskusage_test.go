@@ -0,0 +1,59 @@
+package main
+
+import (
+    "fmt"
+    "sort"
+    "testing"
+    "time"
+)
+
+func TestRequestDiskUsageWorkers(t *testing.T) {
+    falcon := &Falcon{}
+
+    var requests []<-chan DiskUsage
+    for index := 0; index < 10; index++ {
+        requests = append(requests, falcon.requestDiskUsage(fmt.Sprintf("com.example.app%d", index)))
+    }
+
+    //A second request for the same package waits for the first one
+    requests = append(requests, falcon.requestDiskUsage("com.example.app0"))
+
+    falcon.diskUsage.Lock()
+    workers := falcon.diskUsage.workers
+    falcon.diskUsage.Unlock()
+    if workers > diskUsageWorkers {
+        t.Errorf("%d workers were started", workers)
+    }
+
+    for index, request := range requests {
+        select {
+        case <-request:
+        case <-time.After(5 * time.Second):
+            t.Fatalf("request %d was never answered", index)
+        }
+    }
+
+    if _, ok := falcon.cachedDiskUsage("com.example.app9"); !ok {
+        t.Error("the disk usage was not cached")
+    }
+}
+
+func TestAppsBySizeUnmeasured(t *testing.T) {
+    appList := Applications{
+        Application{Package: "unmeasured", Sort: "a"},
+        Application{Package: "small", Sort: "b"},
+        Application{Sort: "c"},
+        Application{Package: "large", Sort: "d"},
+    }
+
+    sort.Sort(AppsBySize{Applications: appList, Sizes: map[string]int64{"small": 10, "large": 20}})
+
+    var order []string
+    for _, app := range appList {
+        order = append(order, app.Sort)
+    }
+
+    if fmt.Sprint(order) != "[d b a c]" {
+        t.Errorf("unexpected order %v", order)
+    }
+}
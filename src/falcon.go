@@ -1,21 +1,55 @@
 package main
 
 import (
+    "encoding/json"
     "fmt"
+    "github.com/gosexy/gettext"
+    "io/ioutil"
     "launchpad.net/go-unityscopes/v2"
     "log"
     "strings"
+    "sync"
 )
 
 type Falcon struct {
     base *scopes.ScopeBase
 
-    iconPack string
+    iconPacksLock sync.RWMutex
+    iconPacks []string
+    iconPacksFile string
     iconPackFile string
-    iconPackMap map[string]interface{}
+    iconPackMaps map[string]IconPackMapping
+
+    iconOverridesFile string
+    iconOverrides map[string]string
+
+    iconShape int64
+    iconCache IconCache
 
     favFile string
     favorites []string
+
+    containers []LibertineContainer
+
+    historyFile string
+    history map[string]LaunchRecord
+
+    groupsFile string
+    groups map[string]string
+
+    dismissedFile string
+    dismissed map[string]int64
+
+    diskUsage DiskUsageCache
+
+    updatesFile string
+    updates UpdateCache
+
+    packageManager PackageManager
+
+    customFile string
+    customEntries []CustomEntry
+    customDrafts CustomDrafts
 }
 
 func (falcon *Falcon) Preview(result *scopes.Result, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply, cancelled <-chan bool) error {
@@ -26,11 +60,15 @@ func (falcon *Falcon) Preview(result *scopes.Result, metadata *scopes.ActionMeta
 
     var err error
     if typ == "app" {
-        err = falcon.appPreview(result, metadata, reply)
+        err = falcon.appPreview(result, metadata, reply, cancelled)
     } else if typ == "icon-pack" {
         err = falcon.iconPackPreview(result, metadata, reply)
     } else if typ == "icon-pack-utility" {
         err = falcon.iconPackUtilityPreview(result, metadata, reply)
+    } else if typ == "custom-entry" {
+        err = falcon.customEntryPreview(result, metadata, reply)
+    } else if typ == "store-app" {
+        err = falcon.storeAppPreview(result, metadata, reply)
     } else {
         log.Fatalln("unknown result type")
     }
@@ -40,15 +78,24 @@ func (falcon *Falcon) Preview(result *scopes.Result, metadata *scopes.ActionMeta
 
 func (falcon *Falcon) Search(query *scopes.CannedQuery, metadata *scopes.SearchMetadata, reply *scopes.SearchReply, cancelled <-chan bool) error {
     q := query.QueryString()
-    log.Println(fmt.Sprintf("query: %s", q))
+    department := query.DepartmentID()
+    log.Println(fmt.Sprintf("query: %s, department: %s", q, department))
+
+    var settings Settings
+    falcon.base.Settings(&settings)
+    if falcon.iconShape != settings.IconShape {
+        falcon.iconShape = settings.IconShape
+        falcon.resetIconCache()
+    }
+
+    if department == "icon-packs" {
+        falcon.registerDepartments(q, reply)
 
-    if q == "icon-packs" {
-        //TODO support searching within the icon packs
-        if err := falcon.iconPackSearch(q, reply); err != nil {
+        if err := falcon.iconPackSearch(query, reply); err != nil {
             log.Fatalln(err)
         }
     } else {
-        if err := falcon.appSearch(q, reply); err != nil {
+        if err := falcon.appSearch(query, metadata, reply); err != nil {
             log.Fatalln(err)
         }
     }
@@ -58,8 +105,23 @@ func (falcon *Falcon) Search(query *scopes.CannedQuery, metadata *scopes.SearchM
 
 func (falcon *Falcon) PerformAction(result *scopes.Result, metadata *scopes.ActionMetadata, widgetId, actionId string) (*scopes.ActivationResponse, error) {
     var resp *scopes.ActivationResponse
-    if strings.Contains(actionId, "icon-pack:") {
+    //The app icon actions carry a file path, so they are matched first and only by their prefix
+    if strings.HasPrefix(actionId, "app-icon:") || strings.HasPrefix(widgetId, "app-icon:") {
+        resp = falcon.appIconPerformAction(result, metadata, widgetId, actionId)
+    } else if strings.Contains(actionId, "icon-pack:") {
         resp = falcon.iconPackPerformAction(result, metadata, widgetId, actionId)
+    } else if strings.Contains(actionId, "store:") {
+        resp = falcon.storePerformAction(result, metadata, widgetId, actionId)
+    } else if strings.Contains(actionId, "uninstall:") {
+        resp = falcon.uninstallPerformAction(result, metadata, widgetId, actionId)
+    } else if strings.Contains(actionId, "data:") {
+        resp = falcon.appDataPerformAction(result, metadata, widgetId, actionId)
+    } else if strings.Contains(actionId, "new:") {
+        resp = falcon.newAppPerformAction(result, metadata, widgetId, actionId)
+    } else if strings.Contains(actionId, "group:") {
+        resp = falcon.groupPerformAction(result, metadata, widgetId, actionId)
+    } else if strings.Contains(actionId, "custom:") || strings.Contains(widgetId, "custom:") {
+        resp = falcon.customPerformAction(result, metadata, widgetId, actionId)
     } else {
         resp = falcon.appPerformAction(result, metadata, widgetId, actionId)
     }
@@ -78,6 +140,11 @@ func (falcon *Falcon) Activate(result *scopes.Result, metadata *scopes.ActionMet
         resp = falcon.appActivate(result, metadata)
     } else if typ == "icon-pack-utility" {
         resp = falcon.iconPackActivate(result, metadata)
+    } else if typ == "icon-packs" {
+        query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "icon-packs")
+        resp = scopes.NewActivationResponseForQuery(query)
+    } else if typ == "custom-entry" || typ == "store-app" {
+        resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
     } else {
         resp = scopes.NewActivationResponse(scopes.ActivationNotHandled)
     }
@@ -85,24 +152,67 @@ func (falcon *Falcon) Activate(result *scopes.Result, metadata *scopes.ActionMet
     return resp, nil
 }
 
+//Saves the data as json, used for everything Falcon keeps in its cache directory
+func writeJsonFile(path string, data interface{}) error {
+    content, err := json.MarshalIndent(data, "", "    ")
+    if err != nil {
+        return err
+    }
+
+    return ioutil.WriteFile(path, content, 0644)
+}
+
 func (falcon *Falcon) SetScopeBase(base *scopes.ScopeBase) {
     falcon.base = base
 
+    gettext.BindTextdomain("falcon.bhdouglass", fmt.Sprintf("%s/../share/locale", falcon.base.ScopeDirectory()))
+
     if falcon.favFile == "" {
         falcon.favFile = fmt.Sprintf("%s/favorites.txt", falcon.base.CacheDirectory())
         falcon.loadFavorites()
     }
 
-    if falcon.iconPackFile == "" {
+    if falcon.historyFile == "" {
+        falcon.historyFile = fmt.Sprintf("%s/history.json", falcon.base.CacheDirectory())
+        falcon.loadHistory()
+    }
+
+    if falcon.groupsFile == "" {
+        falcon.groupsFile = fmt.Sprintf("%s/groups.json", falcon.base.CacheDirectory())
+        falcon.loadGroups()
+    }
+
+    if falcon.updatesFile == "" {
+        falcon.updatesFile = fmt.Sprintf("%s/updates.json", falcon.base.CacheDirectory())
+        falcon.loadUpdates()
+    }
+
+    if falcon.dismissedFile == "" {
+        falcon.dismissedFile = fmt.Sprintf("%s/dismissed.json", falcon.base.CacheDirectory())
+        falcon.loadDismissed()
+    }
+
+    if falcon.customFile == "" {
+        falcon.customFile = fmt.Sprintf("%s/custom.json", falcon.base.CacheDirectory())
+        falcon.loadCustomEntries()
+    }
+
+    if falcon.iconOverridesFile == "" {
+        falcon.iconOverridesFile = fmt.Sprintf("%s/icons.json", falcon.base.CacheDirectory())
+        falcon.loadIconOverrides()
+    }
+
+    if falcon.iconPacksFile == "" {
         falcon.iconPackFile = fmt.Sprintf("%s/iconPack.txt", falcon.base.CacheDirectory())
-        falcon.loadIconPack()
+        falcon.iconPacksFile = fmt.Sprintf("%s/iconPacks.json", falcon.base.CacheDirectory())
+        falcon.loadIconPacks()
     }
 }
 
 func main() {
     log.Println("launching falcon")
 
-    scope := &Falcon{}
+    scope := &Falcon{packageManager: ClickPackageManager{}}
     if err := scopes.Run(scope); err != nil {
         log.Fatalln(err)
     }
@@ -48,7 +48,7 @@ func (falcon *Falcon) unfavorite(appId string) {
 
 func (falcon *Falcon) saveFavorites() {
     data := []byte(strings.Join(falcon.favorites, "\n"))
-    if err := ioutil.WriteFile(falcon.favFile, data, 0777); err != nil {
+    if err := ioutil.WriteFile(falcon.favFile, data, 0644); err != nil {
         log.Println(err)
     }
 }
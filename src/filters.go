@@ -0,0 +1,146 @@
+package main
+
+import (
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "sort"
+)
+
+type AppFilters struct {
+    Sources       []string
+    FavoritesOnly bool
+    Sort          string
+}
+
+func (falcon *Falcon) newSourceFilter() *scopes.OptionSelectorFilter {
+    filter := scopes.NewOptionSelectorFilter("source", "Source", true)
+    filter.AddOption("click", "Apps")
+    filter.AddOption("system", "System Apps")
+    filter.AddOption("desktop", "Desktop Apps")
+    filter.AddOption("scope", "Scopes")
+    filter.AddOption("custom", "Launcher Entries")
+
+    return filter
+}
+
+func (falcon *Falcon) newFavoritesFilter() *scopes.SwitchFilter {
+    return scopes.NewSwitchFilter("favorites", "Favorites only")
+}
+
+func (falcon *Falcon) newSortFilter() *scopes.RadioButtonsFilter {
+    filter := scopes.NewRadioButtonsFilter("sort", "Sort by")
+    filter.AddOption("name", "Name")
+    filter.AddOption("recent", "Recently used")
+    filter.AddOption("frequency", "Most used")
+    filter.AddOption("installed", "Install date")
+    filter.AddOption("storage", "Storage")
+
+    return filter
+}
+
+//The filter state comes from json, so option lists show up as []interface{} instead of the []string the binding expects
+func (falcon *Falcon) filterOptions(state scopes.FilterState, id string) []string {
+    var options []string
+
+    if value, ok := state[id].([]string); ok {
+        options = value
+    } else if value, ok := state[id].([]interface{}); ok {
+        for _, option := range value {
+            if str, ok := option.(string); ok {
+                options = append(options, str)
+            }
+        }
+    }
+
+    return options
+}
+
+func (falcon *Falcon) pushFilters(state scopes.FilterState, reply *scopes.SearchReply) AppFilters {
+    if state == nil {
+        state = scopes.FilterState{}
+    }
+
+    filters := []scopes.Filter{
+        falcon.newSourceFilter(),
+        falcon.newFavoritesFilter(),
+        falcon.newSortFilter(),
+    }
+
+    if err := reply.PushFilters(filters, state); err != nil {
+        log.Println(err)
+    }
+
+    var appFilters AppFilters
+    appFilters.Sources = falcon.filterOptions(state, "source")
+    appFilters.Sort = "name"
+
+    if value, ok := state["favorites"].(bool); ok {
+        appFilters.FavoritesOnly = value
+    }
+
+    if sorts := falcon.filterOptions(state, "sort"); len(sorts) > 0 {
+        appFilters.Sort = sorts[0]
+    }
+
+    return appFilters
+}
+
+func (falcon *Falcon) appSource(app Application) string {
+    source := "system"
+
+    if !app.IsApp {
+        source = "scope"
+    } else if app.IsCustom {
+        source = "custom"
+    } else if app.IsDesktop {
+        source = "desktop"
+    } else if app.Source == "click" {
+        source = "click"
+    }
+
+    return source
+}
+
+func (falcon *Falcon) filterApps(appList Applications, appFilters AppFilters) Applications {
+    var filtered Applications
+
+    for _, app := range appList {
+        if appFilters.FavoritesOnly && !falcon.isFavorite(app.Id) {
+            continue
+        }
+
+        if len(appFilters.Sources) > 0 {
+            source := falcon.appSource(app)
+
+            found := false
+            for _, s := range appFilters.Sources {
+                if s == source {
+                    found = true
+                    break
+                }
+            }
+
+            if !found {
+                continue
+            }
+        }
+
+        filtered = append(filtered, app)
+    }
+
+    return filtered
+}
+
+func (falcon *Falcon) sortApps(appList Applications, appFilters AppFilters) {
+    if appFilters.Sort == "recent" {
+        sort.Sort(AppsByHistory{Applications: appList, History: falcon.history})
+    } else if appFilters.Sort == "frequency" {
+        sort.Sort(AppsByHistory{Applications: appList, History: falcon.history, Frequency: true})
+    } else if appFilters.Sort == "installed" {
+        sort.Sort(AppsByInstalled{appList})
+    } else if appFilters.Sort == "storage" {
+        sort.Sort(AppsBySize{Applications: appList, Sizes: falcon.diskUsageSizes(appList)})
+    } else {
+        sort.Sort(appList)
+    }
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+    "encoding/json"
+    "github.com/gosexy/gettext"
+    "io/ioutil"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "strings"
+)
+
+//Main categories from the freedesktop menu spec, in the order they are displayed
+var mainCategories = []string{
+    "AudioVideo",
+    "Development",
+    "Education",
+    "Game",
+    "Graphics",
+    "Network",
+    "Office",
+    "Science",
+    "Settings",
+    "System",
+    "Utility",
+}
+
+var groupTitles = map[string]string{
+    "AudioVideo":  "Sound & Video",
+    "Development": "Development",
+    "Education":   "Education",
+    "Game":        "Games",
+    "Graphics":    "Graphics",
+    "Network":     "Internet",
+    "Office":      "Office",
+    "Science":     "Science",
+    "Settings":    "Settings",
+    "System":      "System",
+    "Utility":     "Utilities",
+    "Other":       "Other",
+    "Scopes":      "Scopes",
+}
+
+func (falcon *Falcon) translate(str string) string {
+    return gettext.DGettext("falcon.bhdouglass", str)
+}
+
+func (falcon *Falcon) groupTitle(group string) string {
+    title := group
+    if value, ok := groupTitles[group]; ok {
+        title = value
+    }
+
+    return falcon.translate(title)
+}
+
+//Returns the first main category from the Categories= key of a desktop file
+func (falcon *Falcon) mainCategory(categories string) string {
+    for _, category := range strings.Split(categories, ";") {
+        category = strings.TrimSpace(category)
+        if category == "Audio" || category == "Video" {
+            category = "AudioVideo"
+        }
+
+        for _, main := range mainCategories {
+            if category == main {
+                return main
+            }
+        }
+    }
+
+    return ""
+}
+
+func (falcon *Falcon) appGroup(app Application) string {
+    group := "Other"
+
+    if value, ok := falcon.groups[app.Id]; ok {
+        group = value
+    } else if !app.IsApp {
+        group = "Scopes"
+    } else if app.Category != "" {
+        group = app.Category
+    }
+
+    return group
+}
+
+func (falcon *Falcon) groupList(appList Applications) []string {
+    used := map[string]bool{}
+    for _, app := range appList {
+        used[falcon.appGroup(app)] = true
+    }
+
+    var groupList []string
+    for _, group := range append(mainCategories, "Other", "Scopes") {
+        if used[group] {
+            groupList = append(groupList, group)
+        }
+    }
+
+    return groupList
+}
+
+func (falcon *Falcon) groupPreview(app Application, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply) error {
+    titleWidget := scopes.NewPreviewWidget("title", "header")
+    titleWidget.AddAttributeValue("title", "Change group")
+    titleWidget.AddAttributeValue("subtitle", app.Title)
+
+    var buttons []ActionInfo
+    for _, group := range append(mainCategories, "Other") {
+        buttons = append(buttons, ActionInfo{Id: "group:set:" + group, Label: falcon.groupTitle(group)})
+    }
+
+    if _, ok := falcon.groups[app.Id]; ok {
+        buttons = append(buttons, ActionInfo{Id: "group:reset", Label: "Reset"})
+    }
+
+    actionsWidget := scopes.NewPreviewWidget("actions", "actions")
+    actionsWidget.AddAttributeValue("actions", buttons)
+
+    return falcon.pushPreview(metadata, reply, PreviewColumns{
+        Primary: []scopes.PreviewWidget{titleWidget, actionsWidget},
+    })
+}
+
+func (falcon *Falcon) groupPerformAction(result *scopes.Result, metadata *scopes.ActionMetadata, widgetId, actionId string) *scopes.ActivationResponse {
+    var resp *scopes.ActivationResponse
+
+    var app Application
+    if err := result.Get("app", &app); err != nil {
+        log.Println(err)
+    }
+
+    if actionId == "group:change" {
+        resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+        resp.SetScopeData(PreviewState{Group: true})
+    } else {
+        if actionId == "group:reset" {
+            delete(falcon.groups, app.Id)
+        } else if app.Id != "" {
+            falcon.groups[app.Id] = strings.TrimPrefix(actionId, "group:set:")
+        }
+
+        falcon.saveGroups()
+
+        //redirect to blank search
+        query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
+        resp = scopes.NewActivationResponseForQuery(query)
+    }
+
+    return resp
+}
+
+func (falcon *Falcon) saveGroups() {
+    if err := writeJsonFile(falcon.groupsFile, falcon.groups); err != nil {
+        log.Println(err)
+    }
+}
+
+func (falcon *Falcon) loadGroups() {
+    falcon.groups = map[string]string{}
+
+    content, err := ioutil.ReadFile(falcon.groupsFile)
+    if err != nil {
+        log.Println(err)
+    } else if err := json.Unmarshal(content, &falcon.groups); err != nil {
+        log.Println(err)
+    }
+}
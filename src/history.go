@@ -0,0 +1,42 @@
+package main
+
+import (
+    "encoding/json"
+    "io/ioutil"
+    "log"
+    "time"
+)
+
+func (falcon *Falcon) recordLaunch(appId string) {
+    if appId == "" {
+        return
+    }
+
+    if falcon.history == nil {
+        falcon.history = map[string]LaunchRecord{}
+    }
+
+    record := falcon.history[appId]
+    record.Count++
+    record.Last = time.Now().Unix()
+    falcon.history[appId] = record
+
+    falcon.saveHistory()
+}
+
+func (falcon *Falcon) saveHistory() {
+    if err := writeJsonFile(falcon.historyFile, falcon.history); err != nil {
+        log.Println(err)
+    }
+}
+
+func (falcon *Falcon) loadHistory() {
+    falcon.history = map[string]LaunchRecord{}
+
+    content, err := ioutil.ReadFile(falcon.historyFile)
+    if err != nil {
+        log.Println(err)
+    } else if err := json.Unmarshal(content, &falcon.history); err != nil {
+        log.Println(err)
+    }
+}
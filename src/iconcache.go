@@ -0,0 +1,176 @@
+package main
+
+import (
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "syscall"
+    "unsafe"
+)
+
+//Changes to the watched directories can change which icon an app gets
+const iconWatchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+//The file calls made while resolving icons, the tests replace them to count how many a search makes
+var iconStat = os.Stat
+var iconOpen = os.Open
+
+type IconCache struct {
+    sync.Mutex
+    icons      map[string]string
+    reports    map[string]IconPackReport
+    generation int64
+    fd         int
+    started    bool
+    watching   bool
+    watches    map[int]string
+}
+
+//The directories that are watched, desktop files change when apps are installed or updated
+func (falcon *Falcon) iconWatchPaths() []string {
+    var paths []string
+    paths = append(paths, desktopDirectories...)
+    iconPacks, _ := falcon.activeIconPacks()
+    paths = append(paths, iconPacks...)
+
+    for _, icon := range falcon.iconOverrides {
+        paths = append(paths, filepath.Dir(icon))
+    }
+
+    return paths
+}
+
+//Returns the resolved icon from the cache, the cache is only used while the icon files are being watched
+func (falcon *Falcon) cachedIcon(key string, resolve func() string) string {
+    falcon.iconCache.Lock()
+    if icon, ok := falcon.iconCache.icons[key]; ok {
+        falcon.iconCache.Unlock()
+        return icon
+    }
+    watching := falcon.iconCache.watching
+    generation := falcon.iconCache.generation
+    falcon.iconCache.Unlock()
+
+    icon := resolve()
+
+    //An icon resolved before a reset may come from the old packs, so it is not kept
+    falcon.iconCache.Lock()
+    if watching && generation == falcon.iconCache.generation {
+        falcon.iconCache.icons[key] = icon
+    }
+    falcon.iconCache.Unlock()
+
+    return icon
+}
+
+//Expects the icon cache lock to be held
+func (cache *IconCache) clear() {
+    cache.icons = map[string]string{}
+    cache.reports = map[string]IconPackReport{}
+    cache.generation++
+}
+
+func iconCacheKey(parts ...string) string {
+    return strings.Join(parts, "\x00")
+}
+
+//Forgets the resolved icons and watches the directories of the current icon packs
+func (falcon *Falcon) resetIconCache() {
+    falcon.iconCache.Lock()
+    defer falcon.iconCache.Unlock()
+
+    falcon.iconCache.clear()
+
+    if !falcon.iconCache.started {
+        fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+        if err != nil {
+            log.Println("Could not watch the icons, they will not be cached:")
+            log.Println(err)
+            return
+        }
+
+        falcon.iconCache.fd = fd
+        falcon.iconCache.started = true
+        falcon.iconCache.watching = true
+        falcon.iconCache.watches = map[int]string{}
+
+        go falcon.watchIcons(fd)
+    }
+
+    for wd := range falcon.iconCache.watches {
+        syscall.InotifyRmWatch(falcon.iconCache.fd, uint32(wd))
+    }
+    falcon.iconCache.watches = map[int]string{}
+
+    for _, path := range falcon.iconWatchPaths() {
+        wd, err := syscall.InotifyAddWatch(falcon.iconCache.fd, path, iconWatchMask)
+        if err != nil {
+            if !os.IsNotExist(err) {
+                log.Printf("Could not watch %s: %s", path, err)
+            }
+
+            continue
+        }
+
+        falcon.iconCache.watches[wd] = path
+    }
+}
+
+//Clears the caches whenever something changes in the watched directories, the icon packs are reloaded when one of them changes
+func (falcon *Falcon) watchIcons(fd int) {
+    buffer := make([]byte, 4096)
+
+    for {
+        count, err := syscall.Read(fd, buffer)
+        if err == syscall.EINTR {
+            continue
+        }
+
+        falcon.iconCache.Lock()
+        falcon.iconCache.clear()
+
+        if err != nil || count <= 0 {
+            log.Println("Stopped watching the icons:")
+            log.Println(err)
+
+            //Let the next reset start a new watcher
+            syscall.Close(fd)
+            falcon.iconCache.watching = false
+            falcon.iconCache.started = false
+            falcon.iconCache.watches = map[int]string{}
+            falcon.iconCache.Unlock()
+            return
+        }
+
+        iconPackChanged := false
+        for offset := 0; offset + syscall.SizeofInotifyEvent <= count; {
+            //Copied out of the buffer, a byte slice has no alignment guarantee for the struct
+            var event syscall.InotifyEvent
+            copy((*[syscall.SizeofInotifyEvent]byte)(unsafe.Pointer(&event))[:], buffer[offset:])
+            if path, ok := falcon.iconCache.watches[int(event.Wd)]; ok && falcon.isIconPackDirectory(path) {
+                iconPackChanged = true
+            }
+
+            offset += syscall.SizeofInotifyEvent + int(event.Len)
+        }
+
+        falcon.iconCache.Unlock()
+
+        if iconPackChanged {
+            falcon.refreshIconPacks()
+        }
+    }
+}
+
+func (falcon *Falcon) isIconPackDirectory(path string) bool {
+    iconPacks, _ := falcon.activeIconPacks()
+    for _, dir := range iconPacks {
+        if path == dir {
+            return true
+        }
+    }
+
+    return false
+}
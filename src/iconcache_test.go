@@ -0,0 +1,198 @@
+package main
+
+import (
+    "io/ioutil"
+    "os"
+    "testing"
+    "time"
+)
+
+func newTestIconFalcon(b testing.TB) (*Falcon, string) {
+    dir, err := ioutil.TempDir("", "falcon-icon-cache")
+    if err != nil {
+        b.Fatal(err)
+    }
+
+    mapping := `{"version": 2, "apps": {"com.ubuntu.camera": "camera.png"}, "patterns": {"com.ubuntu.*": "ubuntu.png"}}`
+    if err := ioutil.WriteFile(dir + "/icon-pack.json", []byte(mapping), 0644); err != nil {
+        b.Fatal(err)
+    }
+
+    for _, icon := range []string{"camera.png", "ubuntu.png"} {
+        if err := ioutil.WriteFile(dir + "/" + icon, []byte("icon"), 0644); err != nil {
+            b.Fatal(err)
+        }
+    }
+
+    falcon := &Falcon{iconPacks: []string{dir}}
+    falcon.refreshIconPacks()
+
+    return falcon, dir
+}
+
+func TestIconPackChangeRefreshesMapping(t *testing.T) {
+    falcon, dir := newTestIconFalcon(t)
+    defer os.RemoveAll(dir)
+
+    app := Application{Id: "com.ubuntu.camera"}
+    if icon := falcon.getAppIcon(app, "fallback.png"); icon != dir + "/camera.png" {
+        t.Fatalf("unexpected icon %q", icon)
+    }
+
+    mapping := `{"version": 2, "patterns": {"com.ubuntu.*": "ubuntu.png"}}`
+    if err := ioutil.WriteFile(dir + "/icon-pack.json", []byte(mapping), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    deadline := time.Now().Add(5 * time.Second)
+    for falcon.getAppIcon(app, "fallback.png") != dir + "/ubuntu.png" {
+        if time.Now().After(deadline) {
+            t.Fatal("the icon pack was not reloaded after icon-pack.json changed")
+        }
+
+        time.Sleep(10 * time.Millisecond)
+    }
+}
+
+func TestCachedIconSkipsStaleResults(t *testing.T) {
+    falcon, dir := newTestIconFalcon(t)
+    defer os.RemoveAll(dir)
+
+    //The packs change while the icon is being resolved
+    icon := falcon.cachedIcon("key", func() string {
+        falcon.resetIconCache()
+        return "stale.png"
+    })
+    if icon != "stale.png" {
+        t.Errorf("unexpected icon %q", icon)
+    }
+
+    if icon := falcon.cachedIcon("key", func() string { return "fresh.png" }); icon != "fresh.png" {
+        t.Errorf("an icon resolved before the reset was cached: %q", icon)
+    }
+
+    if icon := falcon.cachedIcon("key", func() string { return "other.png" }); icon != "fresh.png" {
+        t.Errorf("the icon was not cached: %q", icon)
+    }
+}
+
+//Run with -race, the watcher reloads the packs while searches resolve icons
+func TestIconPackRefreshWhileResolving(t *testing.T) {
+    falcon, dir := newTestIconFalcon(t)
+    defer os.RemoveAll(dir)
+    falcon.iconPacksFile = dir + "/iconPacks.json"
+
+    done := make(chan bool)
+    go func() {
+        for index := 0; index < 50; index++ {
+            falcon.refreshIconPacks()
+            falcon.saveIconPacks([]string{dir})
+        }
+
+        close(done)
+    }()
+
+    keys := IconKeys{Id: "com.ubuntu.camera"}
+    for {
+        select {
+        case <-done:
+            return
+        default:
+            falcon.resolveIcon(keys, true)
+            falcon.isIconPackDirectory(dir)
+        }
+    }
+}
+
+//Counts the file calls made while resolving icons until the returned function is called
+func countIconFileCalls() (*int, func()) {
+    calls := 0
+    stat := iconStat
+    open := iconOpen
+
+    iconStat = func(name string) (os.FileInfo, error) {
+        calls++
+        return stat(name)
+    }
+    iconOpen = func(name string) (*os.File, error) {
+        calls++
+        return open(name)
+    }
+
+    return &calls, func() {
+        iconStat = stat
+        iconOpen = open
+    }
+}
+
+func TestWarmIconCacheSkipsFiles(t *testing.T) {
+    falcon, dir := newTestIconFalcon(t)
+    defer os.RemoveAll(dir)
+
+    apps := []Application{
+        Application{Id: "com.ubuntu.camera"},
+        Application{Id: "com.ubuntu.music", DesktopId: "music-app.desktop", IconName: "music"},
+        Application{Id: "org.example.app"},
+    }
+
+    calls, restore := countIconFileCalls()
+    defer restore()
+
+    for _, app := range apps {
+        falcon.getAppIcon(app, "fallback.png")
+    }
+
+    if *calls == 0 {
+        t.Fatal("the cold cache made no file calls, the counter is not used")
+    }
+
+    *calls = 0
+    for _, app := range apps {
+        falcon.getAppIcon(app, "fallback.png")
+    }
+
+    if *calls != 0 {
+        t.Errorf("the warm cache made %d file calls", *calls)
+    }
+}
+
+//Resolving from the packs every time, like when the icons are not watched
+func BenchmarkGetAppIconCold(b *testing.B) {
+    falcon, dir := newTestIconFalcon(b)
+    defer os.RemoveAll(dir)
+
+    app := Application{Id: "com.ubuntu.music", DesktopId: "music-app.desktop", IconName: "music"}
+    calls, restore := countIconFileCalls()
+    defer restore()
+    b.ResetTimer()
+
+    for i := 0; i < b.N; i++ {
+        falcon.iconCache.Lock()
+        falcon.iconCache.icons = map[string]string{}
+        falcon.iconCache.Unlock()
+
+        falcon.getAppIcon(app, "fallback.png")
+    }
+
+    b.Logf("%d file calls for %d icons", *calls, b.N)
+}
+
+func BenchmarkGetAppIconWarm(b *testing.B) {
+    falcon, dir := newTestIconFalcon(b)
+    defer os.RemoveAll(dir)
+
+    app := Application{Id: "com.ubuntu.music", DesktopId: "music-app.desktop", IconName: "music"}
+    falcon.getAppIcon(app, "fallback.png")
+
+    calls, restore := countIconFileCalls()
+    defer restore()
+    b.ResetTimer()
+
+    for i := 0; i < b.N; i++ {
+        falcon.getAppIcon(app, "fallback.png")
+    }
+
+    if *calls != 0 {
+        b.Errorf("%d file calls for %d cached icons", *calls, b.N)
+    }
+}
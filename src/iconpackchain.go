@@ -0,0 +1,172 @@
+package main
+
+import (
+    "encoding/json"
+    "io/ioutil"
+    "log"
+    "os"
+)
+
+//The chain is replaced as a whole and never changed in place, so a snapshot can be used without holding the lock
+func (falcon *Falcon) activeIconPacks() ([]string, map[string]IconPackMapping) {
+    falcon.iconPacksLock.RLock()
+    defer falcon.iconPacksLock.RUnlock()
+
+    return falcon.iconPacks, falcon.iconPackMaps
+}
+
+//Returns where the icon pack is in the chain, or -1 when it isn't used
+func iconPackPosition(iconPacks []string, dir string) int {
+    for index, iconPack := range iconPacks {
+        if iconPack == dir {
+            return index
+        }
+    }
+
+    return -1
+}
+
+func (falcon *Falcon) iconPackPosition(dir string) int {
+    iconPacks, _ := falcon.activeIconPacks()
+
+    return iconPackPosition(iconPacks, dir)
+}
+
+func (falcon *Falcon) addIconPack(dir string) {
+    current, _ := falcon.activeIconPacks()
+    if dir == "" || iconPackPosition(current, dir) >= 0 {
+        return
+    }
+
+    iconPacks := make([]string, len(current), len(current) + 1)
+    copy(iconPacks, current)
+
+    falcon.saveIconPacks(append(iconPacks, dir))
+}
+
+func (falcon *Falcon) removeIconPack(dir string) {
+    current, _ := falcon.activeIconPacks()
+
+    var iconPacks []string
+    for _, iconPack := range current {
+        if iconPack != dir {
+            iconPacks = append(iconPacks, iconPack)
+        }
+    }
+
+    falcon.saveIconPacks(iconPacks)
+}
+
+//Moves the icon pack up (negative offset) or down (positive offset) in the chain
+func (falcon *Falcon) moveIconPack(dir string, offset int) {
+    current, _ := falcon.activeIconPacks()
+
+    index := iconPackPosition(current, dir)
+    target := index + offset
+    if index < 0 || target < 0 || target >= len(current) {
+        return
+    }
+
+    iconPacks := make([]string, len(current))
+    copy(iconPacks, current)
+    iconPacks[index], iconPacks[target] = iconPacks[target], iconPacks[index]
+
+    falcon.saveIconPacks(iconPacks)
+}
+
+func (falcon *Falcon) saveIconPacks(iconPacks []string) {
+    falcon.setIconPacks(iconPacks)
+
+    if err := writeJsonFile(falcon.iconPacksFile, iconPacks); err != nil {
+        log.Println(err)
+    }
+}
+
+//Reads the mappings of the packs before swapping in the new chain, so searches always see a complete one
+func (falcon *Falcon) setIconPacks(iconPacks []string) {
+    iconPackMaps := readIconPackMaps(iconPacks)
+
+    falcon.iconPacksLock.Lock()
+    falcon.iconPacks = iconPacks
+    falcon.iconPackMaps = iconPackMaps
+    falcon.iconPacksLock.Unlock()
+
+    falcon.resetIconCache()
+}
+
+//Reads the mappings again after their files changed
+func (falcon *Falcon) refreshIconPacks() {
+    iconPacks, _ := falcon.activeIconPacks()
+    iconPackMaps := readIconPackMaps(iconPacks)
+
+    //A chain that was changed in the meantime already has fresh mappings
+    falcon.iconPacksLock.Lock()
+    if sameIconPacks(falcon.iconPacks, iconPacks) {
+        falcon.iconPackMaps = iconPackMaps
+    }
+    falcon.iconPacksLock.Unlock()
+
+    falcon.resetIconCache()
+}
+
+func sameIconPacks(a []string, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+
+    for index := range a {
+        if a[index] != b[index] {
+            return false
+        }
+    }
+
+    return true
+}
+
+func readIconPackMaps(iconPacks []string) map[string]IconPackMapping {
+    iconPackMaps := map[string]IconPackMapping{}
+
+    for _, dir := range iconPacks {
+        content, err := ioutil.ReadFile(dir + "/icon-pack.json")
+        if err != nil {
+            log.Println(err)
+            continue
+        }
+
+        iconPackMap, problems := parseIconPackMap(content)
+        for _, problem := range problems {
+            log.Println(problem)
+        }
+
+        iconPackMaps[dir] = iconPackMap
+    }
+
+    return iconPackMaps
+}
+
+func (falcon *Falcon) loadIconPacks() {
+    content, err := ioutil.ReadFile(falcon.iconPacksFile)
+    if err == nil {
+        var iconPacks []string
+        if err := json.Unmarshal(content, &iconPacks); err != nil {
+            log.Println(err)
+        }
+
+        falcon.setIconPacks(iconPacks)
+    } else if content, err := ioutil.ReadFile(falcon.iconPackFile); err == nil {
+        //Older versions only had a single icon pack stored as plain text
+        var iconPacks []string
+        if string(content) != "" {
+            iconPacks = append(iconPacks, string(content))
+        }
+
+        falcon.saveIconPacks(iconPacks)
+
+        if err := os.Remove(falcon.iconPackFile); err != nil {
+            log.Println(err)
+        }
+    } else {
+        log.Println(err)
+        falcon.setIconPacks(nil)
+    }
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "image"
+    _ "image/gif"
+    _ "image/jpeg"
+    _ "image/png"
+    "io/ioutil"
+    "os"
+    "path"
+    "path/filepath"
+    "sort"
+    "strings"
+)
+
+//Icon ids Falcon uses for its own results, icon packs can theme these too
+var falconIconIds = []string{
+    "add-launcher-entry",
+    "find-icon-packs",
+    "find-new-icon-pack",
+    "submit-an-icon-pack",
+    "remove-current-icon-pack",
+    "export-icon-pack-template",
+}
+
+//How many ids are listed per problem in the preview
+const iconPackReportLimit = 25
+
+type IconPackReport struct {
+    Problems  []string
+    Broken    []string
+    Unmapped  []string
+    Stale     []string
+    Covered   int
+    Installed int
+}
+
+//Checks the schema of icon-pack-data.json, fields that are missing or have the wrong type are returned as problems
+func parseIconPackData(content []byte) (IconPack, []string, error) {
+    var iconPack IconPack
+    var problems []string
+
+    var data map[string]interface{}
+    if err := json.Unmarshal(content, &data); err != nil {
+        return iconPack, problems, err
+    }
+
+    for _, key := range []string{"title", "icons"} {
+        if value, ok := data[key].(string); !ok || strings.TrimSpace(value) == "" {
+            return iconPack, problems, fmt.Errorf("icon-pack-data.json needs a \"%s\" string", key)
+        }
+    }
+
+    for _, key := range []string{"icon", "preview"} {
+        if _, ok := data[key]; !ok {
+            problems = append(problems, fmt.Sprintf("icon-pack-data.json has no \"%s\"", key))
+        }
+    }
+
+    for _, key := range []string{"author", "maintainer", "icon", "preview", "description"} {
+        if value, ok := data[key]; ok {
+            if _, ok := value.(string); !ok {
+                problems = append(problems, fmt.Sprintf("\"%s\" in icon-pack-data.json is not a string", key))
+                delete(data, key)
+            }
+        }
+    }
+
+    //Re-encode without the invalid fields so they don't fail the whole pack
+    cleaned, err := json.Marshal(data)
+    if err == nil {
+        err = json.Unmarshal(cleaned, &iconPack)
+    }
+
+    return iconPack, problems, err
+}
+
+func parseIconSection(section string, value interface{}, rules map[string]string, problems []string) []string {
+    entries, ok := value.(map[string]interface{})
+    if !ok {
+        return append(problems, fmt.Sprintf("\"%s\" in icon-pack.json is not an object", section))
+    }
+
+    for key, value := range entries {
+        if file, ok := value.(string); ok && file != "" {
+            rules[strings.ToLower(key)] = file
+        } else {
+            problems = append(problems, fmt.Sprintf("the icon for \"%s\" in icon-pack.json is not a file name", key))
+        }
+    }
+
+    return problems
+}
+
+//Checks the schema of icon-pack.json, rules that are not a name to file name string are skipped
+func parseIconPackMap(content []byte) (IconPackMapping, []string) {
+    mapping := newIconPackMapping()
+    var problems []string
+
+    var data interface{}
+    if err := json.Unmarshal(content, &data); err != nil {
+        return mapping, append(problems, fmt.Sprintf("icon-pack.json is not valid json: %s", err))
+    }
+
+    entries, ok := data.(map[string]interface{})
+    if !ok {
+        return mapping, append(problems, "icon-pack.json is not an object of app ids to icon files")
+    }
+
+    version, versioned := entries["version"].(float64)
+    if !versioned {
+        //The original format, every key is an app id
+        problems = parseIconSection("apps", entries, mapping.Apps, problems)
+        sort.Strings(problems)
+
+        return mapping, problems
+    }
+
+    if int(version) > iconPackMappingVersion {
+        problems = append(problems, fmt.Sprintf("icon-pack.json version %d is newer than this version of Falcon supports", int(version)))
+    }
+
+    for key, value := range entries {
+        if key == "version" {
+            continue
+        } else if key == "apps" {
+            problems = parseIconSection(key, value, mapping.Apps, problems)
+        } else if key == "desktop" {
+            problems = parseIconSection(key, value, mapping.Desktop, problems)
+        } else if key == "icons" {
+            problems = parseIconSection(key, value, mapping.Icons, problems)
+        } else if key == "patterns" {
+            patterns := map[string]string{}
+            problems = parseIconSection(key, value, patterns, problems)
+
+            for pattern, icon := range patterns {
+                if _, err := path.Match(pattern, ""); err != nil {
+                    problems = append(problems, fmt.Sprintf("\"%s\" in icon-pack.json is not a valid pattern", pattern))
+                } else {
+                    mapping.Patterns = append(mapping.Patterns, IconPattern{Pattern: pattern, Icon: icon})
+                }
+            }
+        } else if key == "default" {
+            if file, ok := value.(string); ok {
+                mapping.Default = file
+            } else {
+                problems = append(problems, "\"default\" in icon-pack.json is not a file name")
+            }
+        } else {
+            problems = append(problems, fmt.Sprintf("icon-pack.json has an unknown section \"%s\"", key))
+        }
+    }
+
+    mapping.sortPatterns()
+    sort.Strings(problems)
+
+    return mapping, problems
+}
+
+//Makes sure the icon exists and can be read as an image
+func checkIconFile(path string) error {
+    file, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    if strings.ToLower(filepath.Ext(path)) == ".svg" {
+        header := make([]byte, 4096)
+        count, err := file.Read(header)
+        if err != nil || !bytes.Contains(header[:count], []byte("<svg")) {
+            return fmt.Errorf("%s is not an svg image", filepath.Base(path))
+        }
+
+        return nil
+    }
+
+    if _, _, err := image.DecodeConfig(file); err != nil {
+        return fmt.Errorf("%s is not a readable image", filepath.Base(path))
+    }
+
+    return nil
+}
+
+func (falcon *Falcon) validateIconPackApps(iconPack IconPack, installedApps Applications) IconPackReport {
+    var report IconPackReport
+    report.Problems = append(report.Problems, iconPack.Problems...)
+
+    mapping := newIconPackMapping()
+    content, err := ioutil.ReadFile(iconPack.Icons + "/icon-pack.json")
+    if err != nil {
+        report.Problems = append(report.Problems, fmt.Sprintf("icon-pack.json could not be read: %s", err))
+    } else {
+        var problems []string
+        mapping, problems = parseIconPackMap(content)
+        report.Problems = append(report.Problems, problems...)
+    }
+
+    valid := map[string]bool{}
+    for rule, icon := range mapping.Files() {
+        if err := checkIconFile(iconPack.Icons + "/" + icon); err != nil {
+            report.Broken = append(report.Broken, fmt.Sprintf("%s (%s)", rule, err))
+        } else {
+            valid[icon] = true
+        }
+    }
+
+    ids := map[string]bool{}
+    desktopIds := map[string]bool{}
+    iconNames := map[string]bool{}
+    for _, id := range falconIconIds {
+        ids[id] = true
+    }
+
+    var apps Applications
+    for _, app := range installedApps {
+        if ids[app.Id] {
+            continue
+        }
+
+        ids[app.Id] = true
+        desktopIds[strings.ToLower(app.DesktopId)] = true
+        desktopIds[strings.ToLower(strings.TrimSuffix(app.DesktopId, ".desktop"))] = true
+        iconNames[strings.ToLower(app.IconName)] = true
+        apps = append(apps, app)
+        report.Installed++
+
+        //Like the resolver, the default icon covers apps without a working rule
+        if icon, ok := mapping.Match(appIconKeys(app)); ok && valid[icon] {
+            report.Covered++
+        } else if mapping.Default != "" && valid[mapping.Default] {
+            report.Covered++
+        } else if !ok {
+            report.Unmapped = append(report.Unmapped, app.Id)
+        }
+    }
+
+    for id := range mapping.Apps {
+        if !ids[id] {
+            report.Stale = append(report.Stale, id)
+        }
+    }
+
+    for id := range mapping.Desktop {
+        if !desktopIds[id] {
+            report.Stale = append(report.Stale, "desktop: " + id)
+        }
+    }
+
+    for name := range mapping.Icons {
+        if !iconNames[name] {
+            report.Stale = append(report.Stale, "icon: " + name)
+        }
+    }
+
+    for _, pattern := range mapping.Patterns {
+        used := false
+        for _, app := range apps {
+            if matchPattern(pattern.Pattern, strings.ToLower(app.Id)) || matchPattern(pattern.Pattern, strings.ToLower(app.DesktopId)) {
+                used = true
+                break
+            }
+        }
+
+        if !used {
+            report.Stale = append(report.Stale, "pattern: " + pattern.Pattern)
+        }
+    }
+
+    sort.Strings(report.Broken)
+    sort.Strings(report.Unmapped)
+    sort.Strings(report.Stale)
+
+    return report
+}
+
+func (report IconPackReport) Summary() string {
+    return fmt.Sprintf("%d of %d installed apps covered", report.Covered, report.Installed)
+}
+
+func reportList(title string, items []string) string {
+    if len(items) > iconPackReportLimit {
+        more := len(items) - iconPackReportLimit
+        items = append(items[:iconPackReportLimit:iconPackReportLimit], fmt.Sprintf("and %d more", more))
+    }
+
+    return fmt.Sprintf("<b>%s:</b><br>%s", title, strings.Join(items, "<br>"))
+}
+
+func (report IconPackReport) Details() string {
+    var sections []string
+
+    if len(report.Problems) > 0 {
+        sections = append(sections, reportList("Problems", report.Problems))
+    }
+
+    if len(report.Broken) > 0 {
+        sections = append(sections, reportList("Missing or unreadable icons", report.Broken))
+    }
+
+    if len(report.Unmapped) > 0 {
+        sections = append(sections, reportList("Installed apps without an icon", report.Unmapped))
+    }
+
+    if len(report.Stale) > 0 {
+        sections = append(sections, reportList("Rules that match no installed app", report.Stale))
+    }
+
+    if len(sections) == 0 {
+        return "No problems found"
+    }
+
+    return strings.Join(sections, "<br><br>")
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "testing"
+)
+
+//Checks that every item starts with the matching prefix, error messages from the standard library are left out of the expectations
+func matchPrefixes(items []string, prefixes []string) bool {
+    if len(items) != len(prefixes) {
+        return false
+    }
+
+    for index := range items {
+        if !strings.HasPrefix(items[index], prefixes[index]) {
+            return false
+        }
+    }
+
+    return true
+}
+
+func TestParseIconPackData(t *testing.T) {
+    tests := []struct {
+        name     string
+        content  string
+        fails    bool
+        problems []string
+        iconPack IconPack
+    }{
+        {"malformed json", `{"title": "Pack",`, true, nil, IconPack{}},
+        {"not an object", `["Pack"]`, true, nil, IconPack{}},
+        {"missing title", `{"icons": "icons"}`, true, nil, IconPack{}},
+        {"blank title", `{"title": "  ", "icons": "icons"}`, true, nil, IconPack{}},
+        {"icons is not a string", `{"title": "Pack", "icons": 5}`, true, nil, IconPack{}},
+        {
+            "complete",
+            `{"title": "Pack", "icons": "icons", "icon": "icon.png", "preview": "preview.png", "author": "Someone"}`,
+            false,
+            nil,
+            IconPack{Title: "Pack", Icons: "icons", Icon: "icon.png", Preview: "preview.png", Author: "Someone"},
+        },
+        {
+            "missing icon and preview",
+            `{"title": "Pack", "icons": "icons"}`,
+            false,
+            []string{`icon-pack-data.json has no "icon"`, `icon-pack-data.json has no "preview"`},
+            IconPack{Title: "Pack", Icons: "icons"},
+        },
+        {
+            "wrong field types are dropped",
+            `{"title": "Pack", "icons": "icons", "icon": "icon.png", "preview": ["preview.png"], "author": 5, "description": "Icons"}`,
+            false,
+            []string{`"author" in icon-pack-data.json is not a string`, `"preview" in icon-pack-data.json is not a string`},
+            IconPack{Title: "Pack", Icons: "icons", Icon: "icon.png", Description: "Icons"},
+        },
+    }
+
+    for _, test := range tests {
+        iconPack, problems, err := parseIconPackData([]byte(test.content))
+        if (err != nil) != test.fails {
+            t.Errorf("%s: unexpected error %v", test.name, err)
+            continue
+        }
+
+        if !matchPrefixes(problems, test.problems) {
+            t.Errorf("%s: problems = %q, want %q", test.name, problems, test.problems)
+        }
+
+        if !test.fails && (iconPack.Title != test.iconPack.Title || iconPack.Icons != test.iconPack.Icons || iconPack.Icon != test.iconPack.Icon ||
+            iconPack.Preview != test.iconPack.Preview || iconPack.Author != test.iconPack.Author || iconPack.Description != test.iconPack.Description) {
+            t.Errorf("%s: icon pack = %+v, want %+v", test.name, iconPack, test.iconPack)
+        }
+    }
+}
+
+func TestParseIconPackMap(t *testing.T) {
+    tests := []struct {
+        name     string
+        content  string
+        problems []string
+        apps     map[string]string
+        patterns int
+        fallback string
+    }{
+        {"malformed json", `{"com.example.app": `, []string{"icon-pack.json is not valid json"}, map[string]string{}, 0, ""},
+        {"not an object", `["app.png"]`, []string{"icon-pack.json is not an object of app ids to icon files"}, map[string]string{}, 0, ""},
+        {
+            "flat format",
+            `{"Com.Example.App": "app.png", "com.example.other": 5, "com.example.empty": ""}`,
+            []string{`the icon for "com.example.empty" in icon-pack.json is not a file name`, `the icon for "com.example.other" in icon-pack.json is not a file name`},
+            map[string]string{"com.example.app": "app.png"},
+            0,
+            "",
+        },
+        {
+            "newer version",
+            `{"version": 3, "apps": {"com.example.app": "app.png"}}`,
+            []string{"icon-pack.json version 3 is newer than this version of Falcon supports"},
+            map[string]string{"com.example.app": "app.png"},
+            0,
+            "",
+        },
+        {
+            "wrong section types",
+            `{"version": 2, "apps": ["app.png"], "desktop": {"app.desktop": 1}, "patterns": {"com.*": "com.png", "[": "bad.png"}, "default": 1, "extra": {}}`,
+            []string{
+                `"apps" in icon-pack.json is not an object`,
+                `the icon for "app.desktop" in icon-pack.json is not a file name`,
+                `"[" in icon-pack.json is not a valid pattern`,
+                `"default" in icon-pack.json is not a file name`,
+                `icon-pack.json has an unknown section "extra"`,
+            },
+            map[string]string{},
+            1,
+            "",
+        },
+        {
+            "valid",
+            `{"version": 2, "apps": {"com.example.app": "app.png"}, "patterns": {"com.*": "com.png"}, "default": "default.png"}`,
+            nil,
+            map[string]string{"com.example.app": "app.png"},
+            1,
+            "default.png",
+        },
+    }
+
+    for _, test := range tests {
+        mapping, problems := parseIconPackMap([]byte(test.content))
+
+        sort.Strings(test.problems)
+        if !matchPrefixes(problems, test.problems) {
+            t.Errorf("%s: problems = %q, want %q", test.name, problems, test.problems)
+        }
+
+        if len(mapping.Apps) != len(test.apps) {
+            t.Errorf("%s: apps = %v, want %v", test.name, mapping.Apps, test.apps)
+        }
+        for id, icon := range test.apps {
+            if mapping.Apps[id] != icon {
+                t.Errorf("%s: apps = %v, want %v", test.name, mapping.Apps, test.apps)
+            }
+        }
+
+        if len(mapping.Patterns) != test.patterns || mapping.Default != test.fallback {
+            t.Errorf("%s: patterns = %v, default = %q", test.name, mapping.Patterns, mapping.Default)
+        }
+    }
+}
+
+func TestValidateIconPackApps(t *testing.T) {
+    apps := Applications{
+        Application{Id: "com.example.mapped", DesktopId: "mapped.desktop", IconName: "mapped"},
+        Application{Id: "com.example.unmapped"},
+        Application{Id: "com.example.broken"},
+        Application{Id: "com.example.vector"},
+    }
+
+    tests := []struct {
+        name     string
+        mapping  string
+        files    map[string]string
+        problems []string
+        broken   []string
+        unmapped []string
+        stale    []string
+        covered  int
+    }{
+        {
+            "missing icon-pack.json",
+            "",
+            nil,
+            []string{"icon-pack.json could not be read"},
+            nil,
+            []string{"com.example.broken", "com.example.mapped", "com.example.unmapped", "com.example.vector"},
+            nil,
+            0,
+        },
+        {
+            "malformed icon-pack.json",
+            `{"com.example.mapped": `,
+            nil,
+            []string{"icon-pack.json is not valid json"},
+            nil,
+            []string{"com.example.broken", "com.example.mapped", "com.example.unmapped", "com.example.vector"},
+            nil,
+            0,
+        },
+        {
+            "unreadable images",
+            `{"com.example.mapped": "mapped.png", "com.example.broken": "broken.png", "com.example.vector": "vector.svg"}`,
+            map[string]string{"mapped.png": "png", "broken.png": "not an image", "vector.svg": "not an svg"},
+            nil,
+            []string{"com.example.broken (broken.png is not a readable image)", "com.example.vector (vector.svg is not an svg image)"},
+            []string{"com.example.unmapped"},
+            nil,
+            1,
+        },
+        {
+            "missing image",
+            `{"com.example.mapped": "missing.png"}`,
+            nil,
+            nil,
+            []string{"com.example.mapped (open "},
+            []string{"com.example.broken", "com.example.unmapped", "com.example.vector"},
+            nil,
+            0,
+        },
+        {
+            "stale rules",
+            `{"version": 2, "apps": {"com.example.gone": "app.png", "add-launcher-entry": "app.png"}, "desktop": {"mapped.desktop": "app.png", "gone.desktop": "app.png"}, "icons": {"mapped": "app.png", "gone": "app.png"}, "patterns": {"org.*": "app.png", "com.example.v*": "vector.svg"}}`,
+            map[string]string{"app.png": "png", "vector.svg": "<svg></svg>"},
+            nil,
+            nil,
+            []string{"com.example.broken", "com.example.unmapped"},
+            []string{"com.example.gone", "desktop: gone.desktop", "icon: gone", "pattern: org.*"},
+            2,
+        },
+    }
+
+    for _, test := range tests {
+        dir, err := ioutil.TempDir("", "falcon-icon-pack")
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer os.RemoveAll(dir)
+
+        if test.mapping != "" {
+            if err := ioutil.WriteFile(filepath.Join(dir, "icon-pack.json"), []byte(test.mapping), 0644); err != nil {
+                t.Fatal(err)
+            }
+        }
+
+        for name, content := range test.files {
+            if content == "png" {
+                err = writePng(filepath.Join(dir, name))
+            } else {
+                err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+            }
+
+            if err != nil {
+                t.Fatal(err)
+            }
+        }
+
+        falcon := &Falcon{}
+        report := falcon.validateIconPackApps(IconPack{Icons: dir}, apps)
+
+        if !matchPrefixes(report.Problems, test.problems) {
+            t.Errorf("%s: problems = %q, want %q", test.name, report.Problems, test.problems)
+        }
+        if !matchPrefixes(report.Broken, test.broken) {
+            t.Errorf("%s: broken = %q, want %q", test.name, report.Broken, test.broken)
+        }
+        if !matchPrefixes(report.Unmapped, test.unmapped) {
+            t.Errorf("%s: unmapped = %q, want %q", test.name, report.Unmapped, test.unmapped)
+        }
+        if !matchPrefixes(report.Stale, test.stale) {
+            t.Errorf("%s: stale = %q, want %q", test.name, report.Stale, test.stale)
+        }
+        if report.Covered != test.covered || report.Installed != len(apps) {
+            t.Errorf("%s: %s, want %d covered", test.name, report.Summary(), test.covered)
+        }
+    }
+}
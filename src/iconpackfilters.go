@@ -0,0 +1,182 @@
+package main
+
+import (
+    "fmt"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "os"
+    "sort"
+    "strings"
+)
+
+func (falcon *Falcon) newIconPackSortFilter() *scopes.RadioButtonsFilter {
+    filter := scopes.NewRadioButtonsFilter("icon-pack-sort", "Sort by")
+    filter.AddOption("name", "Name")
+    filter.AddOption("author", "Author")
+    filter.AddOption("coverage", "Coverage")
+    filter.AddOption("installed", "Install date")
+
+    return filter
+}
+
+func (falcon *Falcon) pushIconPackFilters(state scopes.FilterState, reply *scopes.SearchReply) string {
+    if state == nil {
+        state = scopes.FilterState{}
+    }
+
+    filters := []scopes.Filter{
+        falcon.newIconPackSortFilter(),
+    }
+
+    if err := reply.PushFilters(filters, state); err != nil {
+        log.Println(err)
+    }
+
+    sortBy := "name"
+    if sorts := falcon.filterOptions(state, "icon-pack-sort"); len(sorts) > 0 {
+        sortBy = sorts[0]
+    }
+
+    return sortBy
+}
+
+//Matches the query against the title, author, maintainer and description of the icon packs
+func (falcon *Falcon) filterIconPacks(iconPacks []IconPack, query string) []IconPack {
+    query = strings.ToLower(strings.TrimSpace(query))
+    if query == "" {
+        return iconPacks
+    }
+
+    var filtered []IconPack
+    for _, iconPack := range iconPacks {
+        fields := []string{iconPack.Title, iconPack.Author, iconPack.Maintainer, iconPack.Description}
+
+        for _, field := range fields {
+            if strings.Contains(strings.ToLower(field), query) {
+                filtered = append(filtered, iconPack)
+                break
+            }
+        }
+    }
+
+    return filtered
+}
+
+type IconPacks []IconPack
+
+func (slice IconPacks) Len() int {
+    return len(slice)
+}
+
+func (slice IconPacks) Less(a, b int) bool {
+    return strings.ToLower(slice[a].Title) < strings.ToLower(slice[b].Title)
+}
+
+func (slice IconPacks) Swap(a, b int) {
+    slice[a], slice[b] = slice[b], slice[a]
+}
+
+type IconPacksByAuthor struct {
+    IconPacks
+}
+
+func (slice IconPacksByAuthor) Less(a, b int) bool {
+    aAuthor := strings.ToLower(slice.IconPacks[a].Author)
+    bAuthor := strings.ToLower(slice.IconPacks[b].Author)
+    if aAuthor == bAuthor {
+        return slice.IconPacks.Less(a, b)
+    }
+
+    //Packs without an author go last
+    if aAuthor == "" || bAuthor == "" {
+        return bAuthor == ""
+    }
+
+    return aAuthor < bAuthor
+}
+
+type IconPacksByCoverage struct {
+    IconPacks
+    Reports map[string]IconPackReport
+}
+
+func (slice IconPacksByCoverage) Less(a, b int) bool {
+    aCovered := slice.Reports[slice.IconPacks[a].Icons].Covered
+    bCovered := slice.Reports[slice.IconPacks[b].Icons].Covered
+    if aCovered == bCovered {
+        return slice.IconPacks.Less(a, b)
+    }
+
+    return aCovered > bCovered
+}
+
+type IconPacksByInstalled struct {
+    IconPacks
+}
+
+func (slice IconPacksByInstalled) Less(a, b int) bool {
+    if slice.IconPacks[a].Installed == slice.IconPacks[b].Installed {
+        return slice.IconPacks.Less(a, b)
+    }
+
+    return slice.IconPacks[a].Installed > slice.IconPacks[b].Installed
+}
+
+//A pack's report is stale once its files change, the watcher clears every report when the installed apps change
+func iconPackReportKey(iconPack IconPack) string {
+    key := []string{iconPack.Icons, fmt.Sprintf("%d", iconPack.Installed)}
+    for _, file := range []string{iconPack.Icons, iconPack.Icons + "/icon-pack.json"} {
+        if info, err := os.Stat(file); err == nil {
+            key = append(key, fmt.Sprintf("%d", info.ModTime().UnixNano()))
+        }
+    }
+
+    return iconCacheKey(key...)
+}
+
+//Validates the packs against the installed apps, the apps are only looked up when a report is not cached
+func (falcon *Falcon) iconPackReports(iconPacks []IconPack) map[string]IconPackReport {
+    reports := map[string]IconPackReport{}
+
+    var apps Applications
+    appsLoaded := false
+    for _, iconPack := range iconPacks {
+        key := iconPackReportKey(iconPack)
+
+        falcon.iconCache.Lock()
+        report, ok := falcon.iconCache.reports[key]
+        generation := falcon.iconCache.generation
+        falcon.iconCache.Unlock()
+
+        if !ok {
+            if !appsLoaded {
+                apps = falcon.installedApps()
+                appsLoaded = true
+            }
+
+            report = falcon.validateIconPackApps(iconPack, apps)
+
+            falcon.iconCache.Lock()
+            if falcon.iconCache.watching && generation == falcon.iconCache.generation {
+                falcon.iconCache.reports[key] = report
+            }
+            falcon.iconCache.Unlock()
+        }
+
+        reports[iconPack.Icons] = report
+    }
+
+    return reports
+}
+
+func (falcon *Falcon) sortIconPacks(iconPacks []IconPack, sortBy string, reports map[string]IconPackReport) {
+    if sortBy == "author" {
+        sort.Sort(IconPacksByAuthor{IconPacks(iconPacks)})
+    } else if sortBy == "coverage" {
+        sort.Sort(IconPacksByCoverage{IconPacks(iconPacks), reports})
+    } else if sortBy == "installed" {
+        sort.Sort(IconPacksByInstalled{IconPacks(iconPacks)})
+    } else {
+        sort.Sort(IconPacks(iconPacks))
+    }
+}
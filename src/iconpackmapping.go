@@ -0,0 +1,171 @@
+package main
+
+import (
+    "path"
+    "sort"
+    "strings"
+)
+
+//The icon-pack.json version with rule sections, files without a version are a flat map of app ids
+const iconPackMappingVersion = 2
+
+//Everything an icon pack can match an app by
+type IconKeys struct {
+    Id        string
+    DesktopId string
+    IconName  string
+}
+
+type IconPattern struct {
+    Pattern string
+    Icon    string
+}
+
+//The rules of an icon-pack.json, see the README for the precedence
+type IconPackMapping struct {
+    Apps     map[string]string
+    Desktop  map[string]string
+    Icons    map[string]string
+    Patterns []IconPattern
+    Default  string
+}
+
+func newIconPackMapping() IconPackMapping {
+    return IconPackMapping{
+        Apps:    map[string]string{},
+        Desktop: map[string]string{},
+        Icons:   map[string]string{},
+    }
+}
+
+func appIconKeys(app Application) IconKeys {
+    return IconKeys{
+        Id:        app.Id,
+        DesktopId: app.DesktopId,
+        IconName:  app.IconName,
+    }
+}
+
+type iconPatterns []IconPattern
+
+func (slice iconPatterns) Len() int {
+    return len(slice)
+}
+
+//Counts the characters of the pattern that are not wildcards, a [...] class is a wildcard as a whole
+func patternLiterals(pattern string) int {
+    count := 0
+    inClass := false
+    escaped := false
+
+    for _, r := range pattern {
+        if escaped {
+            if !inClass {
+                count++
+            }
+            escaped = false
+        } else if r == '\\' {
+            escaped = true
+        } else if inClass {
+            if r == ']' {
+                inClass = false
+            }
+        } else if r == '[' {
+            inClass = true
+        } else if r != '*' && r != '?' {
+            count++
+        }
+    }
+
+    return count
+}
+
+//More specific patterns (more literal characters) are tried first
+func (slice iconPatterns) Less(a, b int) bool {
+    aLength := patternLiterals(slice[a].Pattern)
+    bLength := patternLiterals(slice[b].Pattern)
+    if aLength == bLength {
+        return slice[a].Pattern < slice[b].Pattern
+    }
+
+    return aLength > bLength
+}
+
+func (slice iconPatterns) Swap(a, b int) {
+    slice[a], slice[b] = slice[b], slice[a]
+}
+
+func (mapping *IconPackMapping) sortPatterns() {
+    sort.Sort(iconPatterns(mapping.Patterns))
+}
+
+func (mapping IconPackMapping) Empty() bool {
+    return len(mapping.Apps) == 0 && len(mapping.Desktop) == 0 && len(mapping.Icons) == 0 && len(mapping.Patterns) == 0 && mapping.Default == ""
+}
+
+func matchPattern(pattern string, value string) bool {
+    if value == "" {
+        return false
+    }
+
+    matched, err := path.Match(pattern, value)
+    return err == nil && matched
+}
+
+//Finds the icon for the app, the default icon is not included
+func (mapping IconPackMapping) Match(keys IconKeys) (string, bool) {
+    id := strings.ToLower(keys.Id)
+    desktopId := strings.ToLower(keys.DesktopId)
+    iconName := strings.ToLower(keys.IconName)
+
+    if icon, ok := mapping.Apps[id]; ok && id != "" {
+        return icon, true
+    }
+
+    if icon, ok := mapping.Desktop[desktopId]; ok && desktopId != "" {
+        return icon, true
+    }
+
+    if icon, ok := mapping.Desktop[strings.TrimSuffix(desktopId, ".desktop")]; ok && desktopId != "" {
+        return icon, true
+    }
+
+    if icon, ok := mapping.Icons[iconName]; ok && iconName != "" {
+        return icon, true
+    }
+
+    for _, pattern := range mapping.Patterns {
+        if matchPattern(pattern.Pattern, id) || matchPattern(pattern.Pattern, desktopId) {
+            return pattern.Icon, true
+        }
+    }
+
+    return "", false
+}
+
+//Every icon file of the mapping, keyed by the rule it belongs to
+func (mapping IconPackMapping) Files() map[string]string {
+    files := map[string]string{}
+
+    for id, icon := range mapping.Apps {
+        files[id] = icon
+    }
+
+    for id, icon := range mapping.Desktop {
+        files["desktop: " + id] = icon
+    }
+
+    for name, icon := range mapping.Icons {
+        files["icon: " + name] = icon
+    }
+
+    for _, pattern := range mapping.Patterns {
+        files["pattern: " + pattern.Pattern] = pattern.Icon
+    }
+
+    if mapping.Default != "" {
+        files["default"] = mapping.Default
+    }
+
+    return files
+}
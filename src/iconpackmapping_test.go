@@ -0,0 +1,181 @@
+package main
+
+import (
+    "image"
+    "image/png"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+const testMapping = `{
+    "version": 2,
+    "apps": {
+        "com.ubuntu.camera": "app.png"
+    },
+    "desktop": {
+        "camera-app.desktop": "desktop.png",
+        "gedit": "gedit.png"
+    },
+    "icons": {
+        "camera": "icon-name.png"
+    },
+    "patterns": {
+        "com.ubuntu.*": "ubuntu.png",
+        "[a-z]*-scope": "scope.png",
+        "com.*": "com.png"
+    },
+    "default": "default.png"
+}`
+
+func TestPatternLiterals(t *testing.T) {
+    tests := []struct {
+        pattern  string
+        literals int
+    }{
+        {"com.ubuntu.*", 11},
+        {"[a-z]*-scope", 6},
+        {"*", 0},
+        {"a?b*c", 3},
+        {"\\*x", 2},
+    }
+
+    for _, test := range tests {
+        if literals := patternLiterals(test.pattern); literals != test.literals {
+            t.Errorf("patternLiterals(%q) = %d, want %d", test.pattern, literals, test.literals)
+        }
+    }
+}
+
+func TestIconPackMappingMatch(t *testing.T) {
+    mapping, problems := parseIconPackMap([]byte(testMapping))
+    if len(problems) > 0 {
+        t.Fatalf("unexpected problems: %v", problems)
+    }
+
+    tests := []struct {
+        name  string
+        keys  IconKeys
+        icon  string
+        found bool
+    }{
+        {"app id wins over everything", IconKeys{Id: "com.ubuntu.camera", DesktopId: "camera-app.desktop", IconName: "camera"}, "app.png", true},
+        {"desktop name wins over icon name", IconKeys{Id: "other", DesktopId: "camera-app.desktop", IconName: "camera"}, "desktop.png", true},
+        {"desktop name without .desktop", IconKeys{Id: "other", DesktopId: "gedit.desktop"}, "gedit.png", true},
+        {"icon name wins over patterns", IconKeys{Id: "com.ubuntu.other", IconName: "camera"}, "icon-name.png", true},
+        {"ids are case insensitive", IconKeys{Id: "COM.UBUNTU.CAMERA"}, "app.png", true},
+        {"most specific pattern first", IconKeys{Id: "com.ubuntu.music"}, "ubuntu.png", true},
+        {"more literal characters win", IconKeys{Id: "com.example-scope"}, "scope.png", true},
+        {"shorter pattern when the longer does not match", IconKeys{Id: "com.example"}, "com.png", true},
+        {"pattern on the desktop name", IconKeys{Id: "weather", DesktopId: "weather-scope"}, "scope.png", true},
+        {"default is not a match", IconKeys{Id: "org.example.app"}, "", false},
+    }
+
+    for _, test := range tests {
+        icon, found := mapping.Match(test.keys)
+        if icon != test.icon || found != test.found {
+            t.Errorf("%s: Match(%+v) = %q, %v, want %q, %v", test.name, test.keys, icon, found, test.icon, test.found)
+        }
+    }
+}
+
+func TestIconPackMappingFlatFormat(t *testing.T) {
+    mapping, problems := parseIconPackMap([]byte(`{"com.ubuntu.Camera": "camera.png", "broken": 1}`))
+    if len(problems) != 1 {
+        t.Errorf("expected one problem, got %v", problems)
+    }
+
+    if icon, found := mapping.Match(IconKeys{Id: "com.ubuntu.camera"}); !found || icon != "camera.png" {
+        t.Errorf("flat mapping did not match the app id: %q, %v", icon, found)
+    }
+}
+
+func writeTestIconPack(t *testing.T, mapping string, icons ...string) string {
+    dir, err := ioutil.TempDir("", "falcon-icon-pack")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if err := ioutil.WriteFile(filepath.Join(dir, "icon-pack.json"), []byte(mapping), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    for _, icon := range icons {
+        if err := ioutil.WriteFile(filepath.Join(dir, icon), []byte("icon"), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    return dir
+}
+
+func TestGetAppIconPrecedence(t *testing.T) {
+    first := writeTestIconPack(t, `{"version": 2, "icons": {"camera": "first.png"}, "default": "first-default.png"}`, "first.png", "first-default.png")
+    defer os.RemoveAll(first)
+
+    second := writeTestIconPack(t, `{"com.ubuntu.music": "second.png"}`, "second.png")
+    defer os.RemoveAll(second)
+
+    falcon := &Falcon{iconPacks: []string{first, second}}
+    falcon.refreshIconPacks()
+
+    tests := []struct {
+        name string
+        app  Application
+        icon string
+    }{
+        {"rule of the first pack", Application{Id: "com.ubuntu.camera", IconName: "camera"}, first + "/first.png"},
+        {"rules of every pack before any default", Application{Id: "com.ubuntu.music"}, second + "/second.png"},
+        {"default when nothing matches", Application{Id: "org.example.app"}, first + "/first-default.png"},
+    }
+
+    for _, test := range tests {
+        if icon := falcon.getAppIcon(test.app, "fallback.png"); icon != test.icon {
+            t.Errorf("%s: getAppIcon = %q, want %q", test.name, icon, test.icon)
+        }
+    }
+
+    if icon := falcon.getIcon("org.example.app", "fallback.png"); icon != "fallback.png" {
+        t.Errorf("getIcon should not use the default icon, got %q", icon)
+    }
+}
+
+func TestValidateIconPackDefault(t *testing.T) {
+    apps := Applications{
+        Application{Id: "com.ubuntu.camera", IconName: "camera"},
+        Application{Id: "org.example.app"},
+    }
+
+    withDefault := writeTestIconPack(t, `{"version": 2, "icons": {"camera": "camera.png"}, "default": "default.png"}`)
+    defer os.RemoveAll(withDefault)
+
+    if err := writePng(withDefault + "/camera.png"); err != nil {
+        t.Fatal(err)
+    }
+
+    falcon := &Falcon{}
+    report := falcon.validateIconPackApps(IconPack{Icons: withDefault}, apps)
+    if report.Covered != 1 || len(report.Unmapped) != 1 || len(report.Broken) != 1 {
+        t.Errorf("a broken default should not cover apps: %+v", report)
+    }
+
+    if err := writePng(withDefault + "/default.png"); err != nil {
+        t.Fatal(err)
+    }
+
+    report = falcon.validateIconPackApps(IconPack{Icons: withDefault}, apps)
+    if report.Covered != 2 || len(report.Unmapped) != 0 || len(report.Broken) != 0 {
+        t.Errorf("a valid default should cover every app: %+v", report)
+    }
+}
+
+func writePng(path string) error {
+    file, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    return png.Encode(file, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+//Where the icon pack templates are exported to
+func (falcon *Falcon) iconPackTemplateDirectory() string {
+    base := filepath.Join(falcon.homeDirectory(), "Documents", "falcon-icon-pack")
+
+    dir := base
+    for count := 2; ; count++ {
+        if _, err := os.Stat(dir); os.IsNotExist(err) {
+            return dir
+        }
+
+        dir = fmt.Sprintf("%s-%d", base, count)
+    }
+}
+
+func copyIconFile(source string, dest string) error {
+    reader, err := os.Open(source)
+    if err != nil {
+        return err
+    }
+    defer reader.Close()
+
+    writer, err := os.Create(dest)
+    if err != nil {
+        return err
+    }
+    defer writer.Close()
+
+    _, err = io.Copy(writer, reader)
+
+    return err
+}
+
+//Writes a skeleton icon pack with every installed app mapped to a copy of its own icon, apps whose icon can't be copied are left out and returned
+func (falcon *Falcon) exportIconPackTemplate() (string, []string, error) {
+    var skipped []string
+
+    dir := falcon.iconPackTemplateDirectory()
+    iconDir := filepath.Join(dir, "icons")
+
+    if err := os.MkdirAll(iconDir, 0755); err != nil {
+        return "", skipped, err
+    }
+
+    apps := map[string]string{}
+    desktop := map[string]string{}
+
+    for _, app := range falcon.installedApps() {
+        if app.Id == "" || app.IsCustom {
+            continue
+        }
+
+        //The app's own icon, not the one from the active icon packs
+        source := strings.TrimPrefix(app.SourceIcon, "file://")
+        file := app.Id + filepath.Ext(source)
+        if err := copyIconFile(source, filepath.Join(iconDir, file)); err != nil {
+            log.Printf("Could not copy the icon of %s: %s", app.Id, err)
+            skipped = append(skipped, app.Id)
+            continue
+        }
+
+        apps[app.Id] = file
+        if app.DesktopId != "" {
+            desktop[app.DesktopId] = file
+        }
+    }
+
+    data := map[string]interface{}{
+        "title":       "My Icon Pack",
+        "icons":       "icons",
+        "author":      "",
+        "maintainer":  "",
+        "description": "",
+    }
+
+    //Falcon's own icon stands in for the pack's icon and preview until they are replaced
+    for _, key := range []string{"icon", "preview"} {
+        file := key + ".png"
+        if err := copyIconFile(filepath.Join(falcon.base.ScopeDirectory(), "icon.png"), filepath.Join(dir, file)); err != nil {
+            log.Printf("Could not write the %s placeholder: %s", key, err)
+            continue
+        }
+
+        data[key] = file
+    }
+
+    if err := writeJsonFile(filepath.Join(dir, "icon-pack-data.json"), data); err != nil {
+        return "", skipped, err
+    }
+
+    mapping := map[string]interface{}{
+        "version": iconPackMappingVersion,
+        "apps":    apps,
+        "desktop": desktop,
+    }
+
+    if err := writeJsonFile(filepath.Join(iconDir, "icon-pack.json"), mapping); err != nil {
+        return "", skipped, err
+    }
+
+    return dir, skipped, nil
+}
+
+func (falcon *Falcon) iconPackTemplatePreview(metadata *scopes.ActionMetadata, reply *scopes.PreviewReply) error {
+    var state PreviewState
+    if err := metadata.ScopeData(&state); err != nil {
+        log.Println(err)
+    }
+
+    titleWidget := scopes.NewPreviewWidget("title", "header")
+    titleWidget.AddAttributeValue("title", "Export icon pack template")
+    titleWidget.AddAttributeValue("subtitle", "Start a new icon pack from the apps you have installed")
+
+    infoWidget := scopes.NewPreviewWidget("info", "text")
+    if state.ExportError != "" {
+        infoWidget.AddAttributeValue("text", fmt.Sprintf("The template could not be exported: %s", state.ExportError))
+    } else if state.Exported != "" {
+        text := fmt.Sprintf("The template was exported to %s", state.Exported)
+        if len(state.Skipped) > 0 {
+            text = fmt.Sprintf("%s<br><br>%s", text, reportList("Left out, their icon could not be copied", state.Skipped))
+        }
+
+        infoWidget.AddAttributeValue("text", text)
+    } else {
+        infoWidget.AddAttributeValue("text", "This creates an icon pack in your Documents folder with every installed app, scope and desktop file mapped to a copy of its own icon. Replace the icons and fill in icon-pack-data.json to make it your own.")
+    }
+
+    var buttons []ActionInfo
+    buttons = append(buttons, ActionInfo{Id: "icon-pack:export", Label: "Export"})
+
+    actionsWidget := scopes.NewPreviewWidget("actions", "actions")
+    actionsWidget.AddAttributeValue("actions", buttons)
+
+    return falcon.pushPreview(metadata, reply, PreviewColumns{
+        Primary: []scopes.PreviewWidget{titleWidget, infoWidget, actionsWidget},
+    })
+}
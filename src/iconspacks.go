@@ -1,7 +1,6 @@
 package main
 
 import (
-    "encoding/json"
     "fmt"
     "io/ioutil"
     "launchpad.net/go-unityscopes/v2"
@@ -9,32 +8,17 @@ import (
     "os"
 )
 
-const iconPackCategoryTemplate = `{
-    "schema-version": 1,
-    "template": {
-        "category-layout": "grid",
-        "collapsed-rows": 0,
-        "card-size": "small"
-    },
-    "components" : {
-        "title": "title",
-        "subtitle": "subtitle",
-        "art": {
-            "field": "art",
-            "aspect-ratio": 1.13
-        }
-    }
-}`
-
 func (falcon *Falcon) iconPackUtilityPreview(result *scopes.Result, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply) error {
     var subtype string
     if err := result.Get("sub-type", &subtype); err != nil {
         log.Println(err)
     }
 
-    if subtype == "reset" {
+    if subtype == "export" {
+        return falcon.iconPackTemplatePreview(metadata, reply)
+    } else if subtype == "reset" {
         titleWidget := scopes.NewPreviewWidget("title", "header")
-        titleWidget.AddAttributeValue("title", "Remove current icon pack")
+        titleWidget.AddAttributeValue("title", "Remove all icon packs")
         titleWidget.AddAttributeValue("subtitle", "Revert back to the default icons")
 
         var buttons []ActionInfo
@@ -43,7 +27,9 @@ func (falcon *Falcon) iconPackUtilityPreview(result *scopes.Result, metadata *sc
         actionsWidget := scopes.NewPreviewWidget("actions", "actions")
         actionsWidget.AddAttributeValue("actions", buttons)
 
-        return reply.PushWidgets(titleWidget, actionsWidget)
+        return falcon.pushPreview(metadata, reply, PreviewColumns{
+            Primary: []scopes.PreviewWidget{titleWidget, actionsWidget},
+        })
     } else if subtype == "find" {
         titleWidget := scopes.NewPreviewWidget("title", "header")
         titleWidget.AddAttributeValue("title", "Find new icon packs")
@@ -55,7 +41,9 @@ func (falcon *Falcon) iconPackUtilityPreview(result *scopes.Result, metadata *sc
         actionsWidget := scopes.NewPreviewWidget("actions", "actions")
         actionsWidget.AddAttributeValue("actions", buttons)
 
-        return reply.PushWidgets(titleWidget, actionsWidget)
+        return falcon.pushPreview(metadata, reply, PreviewColumns{
+            Primary: []scopes.PreviewWidget{titleWidget, actionsWidget},
+        })
     } else {
         titleWidget := scopes.NewPreviewWidget("title", "header")
         titleWidget.AddAttributeValue("title", "Submit an icon pack")
@@ -67,7 +55,9 @@ func (falcon *Falcon) iconPackUtilityPreview(result *scopes.Result, metadata *sc
         actionsWidget := scopes.NewPreviewWidget("actions", "actions")
         actionsWidget.AddAttributeValue("actions", buttons)
 
-        return reply.PushWidgets(titleWidget, actionsWidget)
+        return falcon.pushPreview(metadata, reply, PreviewColumns{
+            Primary: []scopes.PreviewWidget{titleWidget, actionsWidget},
+        })
     }
 
     return nil
@@ -85,54 +75,86 @@ func (falcon *Falcon) iconPackPreview(result *scopes.Result, metadata *scopes.Ac
     previewWidget := scopes.NewPreviewWidget("preview", "image")
     previewWidget.AddAttributeValue("source", iconPack.Preview)
 
-    if err := reply.PushWidgets(titleWidget, previewWidget); err != nil {
-        return err
+    columns := PreviewColumns{
+        Primary: []scopes.PreviewWidget{previewWidget, titleWidget},
+    }
+
+    //The user's own apps come first, the pack's preview image moves to the details
+    if sheet, ok := falcon.previewSheet(iconPack); ok {
+        sheetWidget := scopes.NewPreviewWidget("preview-sheet", "image")
+        sheetWidget.AddAttributeValue("source", sheet)
+
+        columns.Primary = []scopes.PreviewWidget{sheetWidget, titleWidget}
+        columns.Details = append(columns.Details, previewWidget)
     }
 
     if iconPack.Author != "" {
         authorWidget := scopes.NewPreviewWidget("author", "text")
         authorWidget.AddAttributeValue("text", fmt.Sprintf("<b>Author:</b> %s", iconPack.Author))
 
-        if err := reply.PushWidgets(authorWidget); err != nil {
-            return err
-        }
+        columns.Details = append(columns.Details, authorWidget)
     }
 
     if iconPack.Maintainer != "" {
         maintainerWidget := scopes.NewPreviewWidget("maintainer", "text")
         maintainerWidget.AddAttributeValue("text", fmt.Sprintf("<b>Maintainer:</b> %s", iconPack.Maintainer))
 
-        if err := reply.PushWidgets(maintainerWidget); err != nil {
-            return err
-        }
+        columns.Details = append(columns.Details, maintainerWidget)
     }
 
     if iconPack.Description != "" {
         descriptionWidget := scopes.NewPreviewWidget("description", "text")
         descriptionWidget.AddAttributeValue("text", iconPack.Description)
 
-        if err := reply.PushWidgets(descriptionWidget); err != nil {
-            return err
-        }
+        columns.Details = append(columns.Details, descriptionWidget)
     }
 
+    report := falcon.iconPackReports([]IconPack{iconPack})[iconPack.Icons]
 
-    if iconPack.Icons != falcon.iconPack {
-        var buttons []ActionInfo
+    coverageWidget := scopes.NewPreviewWidget("coverage", "text")
+    coverageWidget.AddAttributeValue("title", "Coverage")
+    coverageWidget.AddAttributeValue("text", report.Summary())
+
+    reportWidget := scopes.NewPreviewWidget("coverage-details", "text")
+    reportWidget.AddAttributeValue("title", "Details")
+    reportWidget.AddAttributeValue("text", report.Details())
+
+    columns.Primary = append(columns.Primary, coverageWidget)
+    columns.Extra = append(columns.Extra, reportWidget)
+
+    var buttons []ActionInfo
+    activeIconPacks, _ := falcon.activeIconPacks()
+    position := iconPackPosition(activeIconPacks, iconPack.Icons)
+    if position < 0 {
         buttons = append(buttons, ActionInfo{Id: "icon-pack:install", Label: "Activate"})
 
+        if len(activeIconPacks) > 0 {
+            buttons = append(buttons, ActionInfo{Id: "icon-pack:add", Label: "Add"})
+        }
+    } else {
+        if position > 0 {
+            buttons = append(buttons, ActionInfo{Id: "icon-pack:up", Label: "Move up"})
+        }
+
+        if position < len(activeIconPacks) - 1 {
+            buttons = append(buttons, ActionInfo{Id: "icon-pack:down", Label: "Move down"})
+        }
+
+        buttons = append(buttons, ActionInfo{Id: "icon-pack:remove", Label: "Remove"})
+    }
+
+    if len(buttons) > 0 {
         actionsWidget := scopes.NewPreviewWidget("actions", "actions")
         actionsWidget.AddAttributeValue("actions", buttons)
 
-        if err := reply.PushWidgets(actionsWidget); err != nil {
-            return err
-        }
+        columns.Primary = append(columns.Primary, actionsWidget)
     }
 
-    return nil
+    return falcon.pushPreview(metadata, reply, columns)
 }
 
-func (falcon *Falcon) iconPackSearch(query string, reply *scopes.SearchReply) error {
+//Finds the icon packs installed as click packages
+func (falcon *Falcon) installedIconPacks() []IconPack {
     var iconPacks []IconPack
     baseDir := "/opt/click.ubuntu.com/"
 
@@ -141,50 +163,100 @@ func (falcon *Falcon) iconPackSearch(query string, reply *scopes.SearchReply) er
         log.Println(err)
     } else {
         for _, f := range files {
-            path := baseDir + f.Name() + "/current/icon-pack-data.json";
-            if _, err := os.Stat(path); err == nil {
-                log.Printf("Found icon pack: %s", path)
-
-                content, err := ioutil.ReadFile(path)
-                if err != nil {
-                    log.Printf("Error while reading icon pack: %s", path)
-                    log.Println(err)
-                } else {
-                    var iconPack IconPack
-                    if err = json.Unmarshal(content, &iconPack); err != nil {
-                        log.Printf("Error while parsing icon pack: %s", path)
-                        log.Println(err)
-                    } else {
-                        dir := baseDir + f.Name() + "/current/"
-                        iconPack.Icons = dir + iconPack.Icons
-                        iconPack.Icon = dir + iconPack.Icon
-                        iconPack.Preview = dir + iconPack.Preview
-
-                        iconPacks = append(iconPacks, iconPack)
-                    }
+            dir := baseDir + f.Name() + "/current/"
+            if _, err := os.Stat(dir + "icon-pack-data.json"); err == nil {
+                if iconPack, err := falcon.readIconPack(dir); err == nil {
+                    iconPacks = append(iconPacks, iconPack)
                 }
             }
         }
     }
 
-    iconPackCategory := reply.RegisterCategory("icon-packs", "Installed Icon Packs", "", iconPackCategoryTemplate)
+    iconPacks = append(iconPacks, falcon.localIconPacks()...)
+
+    return iconPacks
+}
+
+//Reads the icon-pack-data.json of the directory, the paths in it are made absolute
+func (falcon *Falcon) readIconPack(dir string) (IconPack, error) {
+    path := dir + "icon-pack-data.json"
+    log.Printf("Found icon pack: %s", path)
+
+    content, err := ioutil.ReadFile(path)
+    if err != nil {
+        log.Printf("Error while reading icon pack: %s", path)
+        log.Println(err)
+        return IconPack{}, err
+    }
+
+    iconPack, problems, err := parseIconPackData(content)
+    if err != nil {
+        log.Printf("Error while parsing icon pack: %s", path)
+        log.Println(err)
+        return iconPack, err
+    }
+
+    iconPack.Problems = problems
+
+    if info, err := os.Stat(path); err == nil {
+        iconPack.Installed = info.ModTime().Unix()
+    }
+    iconPack.Icons = dir + iconPack.Icons
+    iconPack.Icon = dir + iconPack.Icon
+    iconPack.Preview = dir + iconPack.Preview
+
+    return iconPack, nil
+}
+
+func (falcon *Falcon) iconPackSearch(cannedQuery *scopes.CannedQuery, reply *scopes.SearchReply) error {
+    sortBy := falcon.pushIconPackFilters(cannedQuery.FilterState(), reply)
+    iconPacks := falcon.filterIconPacks(falcon.installedIconPacks(), cannedQuery.QueryString())
+
+    var reports map[string]IconPackReport
+    if sortBy == "coverage" {
+        reports = falcon.iconPackReports(iconPacks)
+    }
+
+    falcon.sortIconPacks(iconPacks, sortBy, reports)
+
+    activeCategory := falcon.registerCategory(reply, "active-icon-packs", "Active Icon Packs", NewCategoryTemplate("grid", "", "small"))
+    iconPackCategory := falcon.registerCategory(reply, "icon-packs", "Installed Icon Packs", NewCategoryTemplate("grid", "", "small"))
+
+    //The active packs are listed in the order their icons are used
+    installed := map[string]IconPack{}
+    for _, iconPack := range iconPacks {
+        installed[iconPack.Icons] = iconPack
+    }
+
+    activeIconPacks, _ := falcon.activeIconPacks()
+    for index, dir := range activeIconPacks {
+        iconPack, ok := installed[dir]
+        if !ok {
+            continue
+        }
+
+        result := falcon.newIconPackResult(activeCategory, iconPack, sortBy, reports)
+        result.Set("subtitle", fmt.Sprintf("Priority %d", index + 1))
+
+        if err := reply.Push(result); err != nil {
+            log.Fatalln(err)
+        }
+    }
 
     for index := range iconPacks {
         iconPack := iconPacks[index]
+        if iconPackPosition(activeIconPacks, iconPack.Icons) >= 0 {
+            continue
+        }
 
-        result := scopes.NewCategorisedResult(iconPackCategory)
-        result.SetURI(iconPack.Icons)
-        result.SetTitle(iconPack.Title)
-        result.SetArt(iconPack.Icon)
-        result.Set("type", "icon-pack")
-        result.Set("iconPack", iconPack)
+        result := falcon.newIconPackResult(iconPackCategory, iconPack, sortBy, reports)
 
         if err := reply.Push(result); err != nil {
             log.Fatalln(err)
         }
     }
 
-    utilitiesCategory := reply.RegisterCategory("icon-packs-utils", "Utilities", "", iconPackCategoryTemplate)
+    utilitiesCategory := falcon.registerCategory(reply, "icon-packs-utils", "Utilities", NewCategoryTemplate("grid", "", "small"))
 
     findResult := scopes.NewCategorisedResult(utilitiesCategory)
     findResult.SetURI("https://open-store.io/?sort=relevance&search=icon-packs")
@@ -210,10 +282,22 @@ func (falcon *Falcon) iconPackSearch(query string, reply *scopes.SearchReply) er
         log.Fatalln(err)
     }
 
-    if falcon.iconPack != "" {
+    exportResult := scopes.NewCategorisedResult(utilitiesCategory)
+    exportResult.SetURI("export")
+    exportResult.SetTitle("Export icon pack template")
+    exportResult.SetArt(falcon.getIcon("export-icon-pack-template", falcon.base.ScopeDirectory() + "/export.svg"))
+    exportResult.Set("type", "icon-pack-utility")
+    exportResult.Set("sub-type", "export")
+    exportResult.SetInterceptActivation()
+
+    if err := reply.Push(exportResult); err != nil {
+        log.Fatalln(err)
+    }
+
+    if len(activeIconPacks) > 0 {
         resetResult := scopes.NewCategorisedResult(utilitiesCategory)
         resetResult.SetURI("reset")
-        resetResult.SetTitle("Remove current icon pack")
+        resetResult.SetTitle("Remove all icon packs")
         resetResult.SetArt(falcon.getIcon("remove-current-icon-pack", falcon.base.ScopeDirectory() + "/reset.svg"))
         resetResult.Set("type", "icon-pack-utility")
         resetResult.Set("sub-type", "reset")
@@ -227,15 +311,38 @@ func (falcon *Falcon) iconPackSearch(query string, reply *scopes.SearchReply) er
     return nil
 }
 
+func (falcon *Falcon) newIconPackResult(category *scopes.Category, iconPack IconPack, sortBy string, reports map[string]IconPackReport) *scopes.CategorisedResult {
+    result := scopes.NewCategorisedResult(category)
+    result.SetURI(iconPack.Icons)
+    result.SetTitle(iconPack.Title)
+    result.SetArt(iconPack.Icon)
+    result.Set("type", "icon-pack")
+    result.Set("iconPack", iconPack)
+
+    if report, ok := reports[iconPack.Icons]; ok {
+        result.Set("subtitle", report.Summary())
+    } else if sortBy == "author" {
+        result.Set("subtitle", iconPack.Author)
+    }
+
+    if falcon.iconPackPosition(iconPack.Icons) >= 0 {
+        result.Set("emblem", falcon.base.ScopeDirectory() + "/active.svg")
+    }
+
+    return result
+}
+
 func (falcon *Falcon) iconPackActivate(result *scopes.Result, metadata *scopes.ActionMetadata) *scopes.ActivationResponse {
     var resp *scopes.ActivationResponse
 
     if result.URI() == "reset" {
-        falcon.saveIconPack("")
+        falcon.saveIconPacks(nil)
 
         //redirect to blank search
         query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
         resp = scopes.NewActivationResponseForQuery(query)
+    } else if result.URI() == "export" {
+        resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
     } else {
         resp = scopes.NewActivationResponse(scopes.ActivationNotHandled)
     }
@@ -252,13 +359,48 @@ func (falcon *Falcon) iconPackPerformAction(result *scopes.Result, metadata *sco
             log.Println(err)
         }
 
-        falcon.saveIconPack(iconPack.Icons)
+        falcon.saveIconPacks([]string{iconPack.Icons})
 
         //redirect to blank search
         query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
         resp = scopes.NewActivationResponseForQuery(query)
+    } else if actionId == "icon-pack:add" || actionId == "icon-pack:remove" || actionId == "icon-pack:up" || actionId == "icon-pack:down" {
+        var iconPack IconPack
+        if err := result.Get("iconPack", &iconPack); err != nil {
+            log.Println(err)
+        }
+
+        if actionId == "icon-pack:add" {
+            falcon.addIconPack(iconPack.Icons)
+        } else if actionId == "icon-pack:remove" {
+            falcon.removeIconPack(iconPack.Icons)
+        } else if actionId == "icon-pack:up" {
+            falcon.moveIconPack(iconPack.Icons, -1)
+        } else {
+            falcon.moveIconPack(iconPack.Icons, 1)
+        }
+
+        //back to the icon packs to show the new order
+        query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "icon-packs")
+        resp = scopes.NewActivationResponseForQuery(query)
+    } else if actionId == "icon-pack:export" {
+        state := PreviewState{}
+
+        dir, skipped, err := falcon.exportIconPackTemplate()
+        if err != nil {
+            log.Println("Error while exporting the icon pack template:")
+            log.Println(err)
+
+            state.ExportError = err.Error()
+        } else {
+            state.Exported = dir
+            state.Skipped = skipped
+        }
+
+        resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+        resp.SetScopeData(state)
     } else if actionId == "icon-pack:reset" {
-        falcon.saveIconPack("")
+        falcon.saveIconPacks(nil)
 
         //redirect to blank search
         query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
@@ -270,53 +412,53 @@ func (falcon *Falcon) iconPackPerformAction(result *scopes.Result, metadata *sco
     return resp
 }
 
-func (falcon *Falcon) saveIconPack(dir string) {
-    if falcon.iconPack != dir {
-        falcon.iconPack = dir
-
-        data := []byte(falcon.iconPack)
-        if err := ioutil.WriteFile(falcon.iconPackFile, data, 0777); err != nil {
-            log.Println(err)
+//Resolves the icon of one of Falcon's own results, through the user's choice and then the icon packs in priority order
+func (falcon *Falcon) getIcon(id string, fallback string) string {
+    return falcon.cachedIcon(iconCacheKey("id", id, fallback), func() string {
+        if icon, ok := falcon.resolveIcon(IconKeys{Id: id}, false); ok {
+            return falcon.shapeIcon(icon)
         }
 
-        falcon.refreshIconPack()
-    }
+        return falcon.shapeIcon(fallback)
+    })
 }
 
-func (falcon *Falcon) getIcon(id string, fallback string) string {
-    iconFile := fallback
-
-    if icon, ok := falcon.iconPackMap[id]; ok {
-        checkFile := falcon.iconPack + "/" + icon.(string)
-        if _, err := os.Stat(checkFile); err == nil {
-            iconFile = checkFile
+//Resolves the icon of an app, the default icons of the packs are used before the app's own icon
+func (falcon *Falcon) getAppIcon(app Application, fallback string) string {
+    return falcon.cachedIcon(iconCacheKey("app", app.Id, app.DesktopId, app.IconName, fallback), func() string {
+        if icon, ok := falcon.resolveIcon(appIconKeys(app), true); ok {
+            return falcon.shapeIcon(icon)
         }
-    }
 
-    return iconFile
+        return falcon.shapeIcon(fallback)
+    })
 }
 
-func (falcon *Falcon) refreshIconPack() {
-    content, err := ioutil.ReadFile(falcon.iconPack + "/icon-pack.json")
-    if err == nil {
-        var i interface{}
-        if err := json.Unmarshal(content, &i); err != nil {
-            log.Println(err)
-        }
-
-        falcon.iconPackMap = i.(map[string]interface{})
-    } else {
-        log.Println(err)
+func (falcon *Falcon) resolveIcon(keys IconKeys, useDefault bool) (string, bool) {
+    if icon, ok := falcon.iconOverride(keys.Id); ok {
+        return icon, true
     }
-}
 
-func (falcon *Falcon) loadIconPack() {
-    content, err := ioutil.ReadFile(falcon.iconPackFile)
-    if err != nil {
-        log.Println(err)
-    } else {
-        falcon.iconPack = string(content)
+    iconPacks, iconPackMaps := falcon.activeIconPacks()
+    for _, dir := range iconPacks {
+        if icon, ok := iconPackMaps[dir].Match(keys); ok {
+            checkFile := dir + "/" + icon
+            if _, err := iconStat(checkFile); err == nil {
+                return checkFile, true
+            }
+        }
+    }
 
-        falcon.refreshIconPack()
+    if useDefault {
+        for _, dir := range iconPacks {
+            if icon := iconPackMaps[dir].Default; icon != "" {
+                checkFile := dir + "/" + icon
+                if _, err := iconStat(checkFile); err == nil {
+                    return checkFile, true
+                }
+            }
+        }
     }
+
+    return "", false
 }
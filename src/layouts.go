@@ -0,0 +1,80 @@
+package main
+
+import (
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+)
+
+//Primary holds the art, header and actions, which go on the left when there is room for more columns
+type PreviewColumns struct {
+    Primary []scopes.PreviewWidget
+    Details []scopes.PreviewWidget
+    Extra   []scopes.PreviewWidget
+}
+
+func widgetIds(widgets []scopes.PreviewWidget) []string {
+    var ids []string
+    for _, widget := range widgets {
+        ids = append(ids, widget.Id())
+    }
+
+    return ids
+}
+
+func (falcon *Falcon) previewLayouts(formFactor string, columns PreviewColumns) []*scopes.ColumnLayout {
+    var all []scopes.PreviewWidget
+    all = append(all, columns.Primary...)
+    all = append(all, columns.Details...)
+    all = append(all, columns.Extra...)
+
+    oneColumn := scopes.NewColumnLayout(1)
+    if err := oneColumn.AddColumn(widgetIds(all)...); err != nil {
+        log.Println(err)
+    }
+
+    layouts := []*scopes.ColumnLayout{oneColumn}
+    if formFactor == "phone" || len(columns.Details) + len(columns.Extra) == 0 {
+        return layouts
+    }
+
+    twoColumns := scopes.NewColumnLayout(2)
+    if err := twoColumns.AddColumn(widgetIds(columns.Primary)...); err != nil {
+        log.Println(err)
+    }
+    if err := twoColumns.AddColumn(widgetIds(append(columns.Details, columns.Extra...))...); err != nil {
+        log.Println(err)
+    }
+    layouts = append(layouts, twoColumns)
+
+    if formFactor != "desktop" || len(columns.Details) == 0 || len(columns.Extra) == 0 {
+        return layouts
+    }
+
+    threeColumns := scopes.NewColumnLayout(3)
+    if err := threeColumns.AddColumn(widgetIds(columns.Primary)...); err != nil {
+        log.Println(err)
+    }
+    if err := threeColumns.AddColumn(widgetIds(columns.Details)...); err != nil {
+        log.Println(err)
+    }
+    if err := threeColumns.AddColumn(widgetIds(columns.Extra)...); err != nil {
+        log.Println(err)
+    }
+    layouts = append(layouts, threeColumns)
+
+    return layouts
+}
+
+//Registers the column layouts for the form factor and pushes all the widgets of a preview
+func (falcon *Falcon) pushPreview(metadata *scopes.ActionMetadata, reply *scopes.PreviewReply, columns PreviewColumns) error {
+    if err := reply.RegisterLayout(falcon.previewLayouts(metadata.FormFactor(), columns)...); err != nil {
+        log.Println(err)
+    }
+
+    var widgets []scopes.PreviewWidget
+    widgets = append(widgets, columns.Primary...)
+    widgets = append(widgets, columns.Details...)
+    widgets = append(widgets, columns.Extra...)
+
+    return reply.PushWidgets(widgets...)
+}
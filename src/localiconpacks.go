@@ -0,0 +1,258 @@
+package main
+
+import (
+    "archive/zip"
+    "errors"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+//The most an icon pack zip may unpack to, protects against broken or malicious archives
+const maxIconPackSize = 64 * 1024 * 1024
+
+//Where users can drop icon packs that are not published as click packages
+func (falcon *Falcon) localIconPackDirectory() string {
+    return filepath.Join(falcon.homeDirectory(), ".local", "share", "falcon", "icon-packs")
+}
+
+func (falcon *Falcon) extractedIconPackDirectory() string {
+    return filepath.Join(falcon.base.CacheDirectory(), "icon-packs")
+}
+
+//Finds icon packs in plain directories and zip archives in the local icon pack directory
+func (falcon *Falcon) localIconPacks() []IconPack {
+    var iconPacks []IconPack
+    baseDir := falcon.localIconPackDirectory()
+
+    files, err := ioutil.ReadDir(baseDir)
+    if err != nil {
+        if !os.IsNotExist(err) {
+            log.Println(err)
+        }
+
+        return iconPacks
+    }
+
+    zips := map[string]bool{}
+    for _, f := range files {
+        path := filepath.Join(baseDir, f.Name())
+
+        dir := ""
+        if f.IsDir() {
+            dir = iconPackRoot(path)
+        } else if strings.ToLower(filepath.Ext(f.Name())) == ".zip" {
+            zips[extractedIconPackName(f)] = true
+
+            extracted, err := falcon.extractIconPack(path, f)
+            if err != nil {
+                log.Printf("Error while extracting icon pack: %s", path)
+                log.Println(err)
+                continue
+            }
+
+            dir = iconPackRoot(extracted)
+        }
+
+        if dir == "" {
+            continue
+        }
+
+        if iconPack, err := falcon.readIconPack(dir + "/"); err == nil {
+            iconPacks = append(iconPacks, iconPack)
+        }
+    }
+
+    falcon.cleanExtractedIconPacks(zips)
+
+    return iconPacks
+}
+
+func extractedIconPackName(info os.FileInfo) string {
+    return strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
+}
+
+//Removes the extracted packs whose zip was deleted
+func (falcon *Falcon) cleanExtractedIconPacks(zips map[string]bool) {
+    files, err := ioutil.ReadDir(falcon.extractedIconPackDirectory())
+    if err != nil {
+        return
+    }
+
+    for _, f := range files {
+        //Extractions in progress are left alone
+        if !zips[f.Name()] && !strings.HasSuffix(f.Name(), ".tmp") {
+            if err := os.RemoveAll(filepath.Join(falcon.extractedIconPackDirectory(), f.Name())); err != nil {
+                log.Println(err)
+            }
+        }
+    }
+}
+
+//Returns the directory with the icon-pack-data.json, either the directory itself or its only subdirectory
+func iconPackRoot(dir string) string {
+    if _, err := os.Stat(filepath.Join(dir, "icon-pack-data.json")); err == nil {
+        return dir
+    }
+
+    files, err := ioutil.ReadDir(dir)
+    if err != nil || len(files) != 1 || !files[0].IsDir() {
+        return ""
+    }
+
+    subdir := filepath.Join(dir, files[0].Name())
+    if _, err := os.Stat(filepath.Join(subdir, "icon-pack-data.json")); err == nil {
+        return subdir
+    }
+
+    return ""
+}
+
+//Extracts the zip into the cache, it is only extracted again when the zip changes
+func (falcon *Falcon) extractIconPack(path string, info os.FileInfo) (string, error) {
+    dest := filepath.Join(falcon.extractedIconPackDirectory(), extractedIconPackName(info))
+
+    if destInfo, err := os.Stat(dest); err == nil && !destInfo.ModTime().Before(info.ModTime()) {
+        return dest, nil
+    }
+
+    tmp := dest + ".tmp"
+    os.RemoveAll(tmp)
+
+    if err := unzipIconPack(path, tmp); err != nil {
+        os.RemoveAll(tmp)
+        return "", err
+    }
+
+    if err := verifyIconPack(tmp); err != nil {
+        os.RemoveAll(tmp)
+        return "", err
+    }
+
+    os.RemoveAll(dest)
+    if err := os.Rename(tmp, dest); err != nil {
+        os.RemoveAll(tmp)
+        return "", err
+    }
+
+    return dest, nil
+}
+
+func unzipIconPack(path string, dest string) error {
+    archive, err := zip.OpenReader(path)
+    if err != nil {
+        return err
+    }
+    defer archive.Close()
+
+    tooLarge := fmt.Errorf("%s is larger than %s when unpacked", filepath.Base(path), formatSize(maxIconPackSize))
+
+    //The sizes in the headers give a quick answer for honest archives
+    var total uint64
+    for _, file := range archive.File {
+        total += file.UncompressedSize64
+        if total > maxIconPackSize {
+            return tooLarge
+        }
+    }
+
+    //They can't be trusted, so every byte written counts against the limit as well
+    var remaining int64 = maxIconPackSize
+    for _, file := range archive.File {
+        target := filepath.Join(dest, file.Name)
+        if !strings.HasPrefix(target, filepath.Clean(dest) + string(os.PathSeparator)) {
+            return fmt.Errorf("%s contains an invalid path: %s", filepath.Base(path), file.Name)
+        }
+
+        if file.FileInfo().IsDir() {
+            if err := os.MkdirAll(target, 0755); err != nil {
+                return err
+            }
+
+            continue
+        }
+
+        if !file.Mode().IsRegular() {
+            return fmt.Errorf("%s contains a file that is not a regular file: %s", filepath.Base(path), file.Name)
+        }
+
+        written, err := unzipFile(file, target, remaining)
+        if err == errIconPackTooLarge {
+            return tooLarge
+        } else if err != nil {
+            return err
+        }
+
+        remaining -= written
+    }
+
+    return nil
+}
+
+var errIconPackTooLarge = errors.New("icon pack is too large")
+
+//Writes at most remaining bytes, a file that is any larger fails instead of being cut off
+func unzipFile(file *zip.File, target string, remaining int64) (int64, error) {
+    if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+        return 0, err
+    }
+
+    reader, err := file.Open()
+    if err != nil {
+        return 0, err
+    }
+    defer reader.Close()
+
+    writer, err := os.Create(target)
+    if err != nil {
+        return 0, err
+    }
+    defer writer.Close()
+
+    written, err := io.Copy(writer, io.LimitReader(reader, remaining + 1))
+    if err != nil {
+        return written, err
+    } else if written > remaining {
+        return written, errIconPackTooLarge
+    }
+
+    return written, nil
+}
+
+//Makes sure the extracted zip is a usable icon pack before it is shown
+func verifyIconPack(dir string) error {
+    root := iconPackRoot(dir)
+    if root == "" {
+        return fmt.Errorf("no icon-pack-data.json found")
+    }
+
+    content, err := ioutil.ReadFile(filepath.Join(root, "icon-pack-data.json"))
+    if err != nil {
+        return err
+    }
+
+    iconPack, _, err := parseIconPackData(content)
+    if err != nil {
+        return err
+    }
+
+    content, err = ioutil.ReadFile(filepath.Join(root, iconPack.Icons, "icon-pack.json"))
+    if err != nil {
+        return err
+    }
+
+    mapping, problems := parseIconPackMap(content)
+    if mapping.Empty() {
+        if len(problems) > 0 {
+            return errors.New(problems[0])
+        }
+
+        return fmt.Errorf("icon-pack.json has no icons")
+    }
+
+    return nil
+}
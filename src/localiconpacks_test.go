@@ -0,0 +1,100 @@
+package main
+
+import (
+    "archive/zip"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func writeTestZip(t *testing.T, path string, files map[string]string) {
+    out, err := os.Create(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer out.Close()
+
+    archive := zip.NewWriter(out)
+    for name, content := range files {
+        writer, err := archive.Create(name)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if _, err := writer.Write([]byte(content)); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    if err := archive.Close(); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestUnzipFileLimit(t *testing.T) {
+    dir, err := ioutil.TempDir("", "falcon-unzip")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    path := filepath.Join(dir, "pack.zip")
+    writeTestZip(t, path, map[string]string{"a.png": strings.Repeat("a", 10), "b.png": strings.Repeat("b", 10)})
+
+    archive, err := zip.OpenReader(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer archive.Close()
+
+    //Two files of 10 bytes don't fit in 15 bytes, the second one fails instead of being cut off
+    var remaining int64 = 15
+    var failed error
+    for _, file := range archive.File {
+        written, err := unzipFile(file, filepath.Join(dir, "out", file.Name), remaining)
+        if err != nil {
+            failed = err
+            break
+        }
+
+        remaining -= written
+    }
+
+    if failed != errIconPackTooLarge {
+        t.Errorf("expected the limit to be reached, got %v", failed)
+    }
+
+    if remaining != 5 {
+        t.Errorf("expected 5 bytes to remain, got %d", remaining)
+    }
+}
+
+func TestUnzipIconPack(t *testing.T) {
+    dir, err := ioutil.TempDir("", "falcon-unzip")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    path := filepath.Join(dir, "pack.zip")
+    writeTestZip(t, path, map[string]string{
+        "pack/icon-pack-data.json": `{"title": "Pack", "icons": "icons"}`,
+        "pack/icons/icon-pack.json": `{"com.ubuntu.camera": "camera.png"}`,
+    })
+
+    if err := unzipIconPack(path, filepath.Join(dir, "out")); err != nil {
+        t.Fatal(err)
+    }
+
+    if err := verifyIconPack(filepath.Join(dir, "out")); err != nil {
+        t.Error(err)
+    }
+
+    evil := filepath.Join(dir, "evil.zip")
+    writeTestZip(t, evil, map[string]string{"../escape.png": "icon"})
+    if err := unzipIconPack(evil, filepath.Join(dir, "evil")); err == nil {
+        t.Error("a path outside the destination was extracted")
+    }
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "os"
+    "strings"
+    "time"
+)
+
+const clickDirectory = "/opt/click.ubuntu.com/"
+
+//Indexed by the new_apps setting
+var newAppDays = []int64{0, 1, 3, 7, 14, 30}
+
+func (falcon *Falcon) clickPackage(appId string) string {
+    return strings.Split(appId, "_")[0]
+}
+
+//Returns when a click package was last installed or updated, based on its current version directory and manifest
+func (falcon *Falcon) clickInstalled(pkg string) int64 {
+    var installed int64
+
+    paths := []string{
+        fmt.Sprintf("%s%s/current", clickDirectory, pkg),
+        fmt.Sprintf("%s%s/current/manifest.json", clickDirectory, pkg),
+    }
+
+    for _, path := range paths {
+        if info, err := os.Stat(path); err == nil {
+            if info.ModTime().Unix() > installed {
+                installed = info.ModTime().Unix()
+            }
+        }
+    }
+
+    return installed
+}
+
+func (falcon *Falcon) isNewApp(app Application, days int64) bool {
+    if days <= 0 || app.Source != "click" || app.Installed == 0 {
+        return false
+    }
+
+    if app.Installed < time.Now().Unix() - (days * 24 * 60 * 60) {
+        return false
+    }
+
+    //Launching or dismissing the app after it was installed or updated clears the new status
+    if falcon.history[app.Id].Last >= app.Installed || falcon.dismissed[app.Id] >= app.Installed {
+        return false
+    }
+
+    return true
+}
+
+func (falcon *Falcon) markNewApps(appList Applications, setting int64) {
+    if setting < 0 || setting >= int64(len(newAppDays)) {
+        log.Printf("Unknown new apps setting %d", setting)
+        return
+    }
+
+    for index := range appList {
+        appList[index].IsNew = falcon.isNewApp(appList[index], newAppDays[setting])
+    }
+}
+
+func (falcon *Falcon) newAppPerformAction(result *scopes.Result, metadata *scopes.ActionMetadata, widgetId, actionId string) *scopes.ActivationResponse {
+    var app Application
+    if err := result.Get("app", &app); err != nil {
+        log.Println(err)
+    }
+
+    if actionId == "new:dismiss" && app.Id != "" {
+        falcon.dismissed[app.Id] = time.Now().Unix()
+        falcon.saveDismissed()
+    }
+
+    //redirect to blank search
+    query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
+    return scopes.NewActivationResponseForQuery(query)
+}
+
+func (falcon *Falcon) saveDismissed() {
+    if err := writeJsonFile(falcon.dismissedFile, falcon.dismissed); err != nil {
+        log.Println(err)
+    }
+}
+
+func (falcon *Falcon) loadDismissed() {
+    falcon.dismissed = map[string]int64{}
+
+    content, err := ioutil.ReadFile(falcon.dismissedFile)
+    if err != nil {
+        log.Println(err)
+    } else if err := json.Unmarshal(content, &falcon.dismissed); err != nil {
+        log.Println(err)
+    }
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "os/exec"
+)
+
+type PackageManager interface {
+    Uninstall(pkg string, version string) error
+}
+
+//Removes click packages for the current user with the click tool
+type ClickPackageManager struct{}
+
+func (manager ClickPackageManager) Uninstall(pkg string, version string) error {
+    user := os.Getenv("USER")
+    if user == "" {
+        user = "phablet"
+    }
+
+    output, err := exec.Command("click", "unregister", fmt.Sprintf("--user=%s", user), pkg, version).CombinedOutput()
+    if err != nil {
+        return fmt.Errorf("click unregister %s %s failed: %s (%s)", pkg, version, err, output)
+    }
+
+    return nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+    "crypto/sha1"
+    "fmt"
+    "image"
+    "image/color"
+    "image/draw"
+    "image/png"
+    "io/ioutil"
+    "log"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+)
+
+//The preview sheet is a grid of the user's apps drawn with the icons of a pack
+const previewSheetColumns = 4
+const previewSheetRows = 3
+const previewSheetCell = 96
+const previewSheetPadding = 16
+
+var previewSheetBackground = color.RGBA{R: 0xf5, G: 0xf5, B: 0xf5, A: 0xff}
+
+func isRasterIcon(path string) bool {
+    extension := strings.ToLower(filepath.Ext(path))
+
+    return extension == ".png" || extension == ".jpg" || extension == ".jpeg"
+}
+
+//Favorites first, followed by the most used apps, ties are sorted by the keys installedApps sets
+func (falcon *Falcon) previewSheetApps() Applications {
+    var favorites Applications
+    var others Applications
+
+    for _, app := range falcon.installedApps() {
+        if falcon.isFavorite(app.Id) {
+            favorites = append(favorites, app)
+        } else {
+            others = append(others, app)
+        }
+    }
+
+    sort.Sort(AppsByHistory{favorites, falcon.history, true})
+    sort.Sort(AppsByHistory{others, falcon.history, true})
+
+    return append(favorites, others...)
+}
+
+//Picks the icon the pack gives the app, the app's own icon is used when the pack has none (not the one the active packs resolved)
+func (falcon *Falcon) previewSheetIcon(iconPack IconPack, mapping IconPackMapping, app Application) string {
+    if icon, ok := mapping.Match(appIconKeys(app)); ok {
+        return iconPack.Icons + "/" + icon
+    } else if mapping.Default != "" {
+        return iconPack.Icons + "/" + mapping.Default
+    }
+
+    return strings.TrimPrefix(app.SourceIcon, "file://")
+}
+
+func (falcon *Falcon) previewSheetIcons(iconPack IconPack) []string {
+    mapping := newIconPackMapping()
+    if content, err := ioutil.ReadFile(iconPack.Icons + "/icon-pack.json"); err == nil {
+        mapping, _ = parseIconPackMap(content)
+    }
+
+    var icons []string
+    for _, app := range falcon.previewSheetApps() {
+        icon := falcon.previewSheetIcon(iconPack, mapping, app)
+
+        //Only raster icons can be drawn
+        if !isRasterIcon(icon) {
+            continue
+        }
+
+        if _, err := os.Stat(icon); err != nil {
+            continue
+        }
+
+        icons = append(icons, icon)
+        if len(icons) >= previewSheetColumns * previewSheetRows {
+            break
+        }
+    }
+
+    return icons
+}
+
+func (falcon *Falcon) previewSheetDirectory() string {
+    return filepath.Join(falcon.base.CacheDirectory(), "preview-sheets")
+}
+
+//Sheets are cached per pack, the name changes when the icons, their files or the icon shape change
+func (falcon *Falcon) previewSheetPath(iconPack IconPack, icons []string) string {
+    key := []string{fmt.Sprintf("%d", iconShapeVersion), fmt.Sprintf("%d", falcon.iconShape)}
+    for _, icon := range icons {
+        if info, err := os.Stat(icon); err == nil {
+            key = append(key, fmt.Sprintf("%s:%d", icon, info.ModTime().UnixNano()))
+        }
+    }
+
+    pack := fmt.Sprintf("%x", sha1.Sum([]byte(iconPack.Icons)))
+    sheet := fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join(key, "\n"))))
+
+    return filepath.Join(falcon.previewSheetDirectory(), fmt.Sprintf("%s-%s.png", pack, sheet))
+}
+
+//Draws the image scaled into the rectangle, keeping its aspect ratio
+func drawScaled(dest *image.RGBA, rect image.Rectangle, src image.Image) {
+    source := image.NewRGBA(src.Bounds())
+    draw.Draw(source, source.Bounds(), src, src.Bounds().Min, draw.Src)
+
+    bounds := source.Bounds()
+    scale := float64(rect.Dx()) / float64(bounds.Dx())
+    if float64(rect.Dy()) / float64(bounds.Dy()) < scale {
+        scale = float64(rect.Dy()) / float64(bounds.Dy())
+    }
+
+    width := int(float64(bounds.Dx()) * scale)
+    height := int(float64(bounds.Dy()) * scale)
+    left := rect.Min.X + (rect.Dx() - width) / 2
+    top := rect.Min.Y + (rect.Dy() - height) / 2
+
+    scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+    for y := 0; y < height; y++ {
+        for x := 0; x < width; x++ {
+            fx := (float64(x) + 0.5) / scale + float64(bounds.Min.X)
+            fy := (float64(y) + 0.5) / scale + float64(bounds.Min.Y)
+
+            pixel := sampleBilinear(source, bounds, fx, fy)
+            scaled.SetRGBA(x, y, color.RGBA{
+                R: uint8(pixel[0] + 0.5),
+                G: uint8(pixel[1] + 0.5),
+                B: uint8(pixel[2] + 0.5),
+                A: uint8(pixel[3] + 0.5),
+            })
+        }
+    }
+
+    draw.Draw(dest, image.Rect(left, top, left + width, top + height), scaled, image.ZP, draw.Over)
+}
+
+func (falcon *Falcon) renderPreviewSheet(icons []string, dest string) error {
+    rows := (len(icons) + previewSheetColumns - 1) / previewSheetColumns
+    width := previewSheetColumns * (previewSheetCell + previewSheetPadding) + previewSheetPadding
+    height := rows * (previewSheetCell + previewSheetPadding) + previewSheetPadding
+
+    sheet := image.NewRGBA(image.Rect(0, 0, width, height))
+    draw.Draw(sheet, sheet.Bounds(), &image.Uniform{previewSheetBackground}, image.ZP, draw.Src)
+
+    for index, icon := range icons {
+        file, err := os.Open(icon)
+        if err != nil {
+            log.Println(err)
+            continue
+        }
+
+        src, _, err := image.Decode(file)
+        file.Close()
+        if err != nil {
+            log.Printf("Could not draw %s: %s", icon, err)
+            continue
+        }
+
+        //Show the icons the way they will look in the grid
+        if falcon.iconShape > 0 && falcon.iconShape < int64(len(iconShapes)) {
+            src = renderShapedIcon(src, iconShapes[falcon.iconShape])
+        }
+
+        x := previewSheetPadding + (index % previewSheetColumns) * (previewSheetCell + previewSheetPadding)
+        y := previewSheetPadding + (index / previewSheetColumns) * (previewSheetCell + previewSheetPadding)
+        drawScaled(sheet, image.Rect(x, y, x + previewSheetCell, y + previewSheetCell), src)
+    }
+
+    if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+        return err
+    }
+
+    tmp := dest + ".tmp"
+    output, err := os.Create(tmp)
+    if err != nil {
+        return err
+    }
+
+    err = png.Encode(output, sheet)
+    output.Close()
+    if err == nil {
+        err = os.Rename(tmp, dest)
+    }
+
+    if err != nil {
+        os.Remove(tmp)
+    }
+
+    return err
+}
+
+//Removes the older sheets of the pack
+func (falcon *Falcon) cleanPreviewSheets(current string) {
+    prefix := strings.SplitN(filepath.Base(current), "-", 2)[0]
+
+    files, err := ioutil.ReadDir(falcon.previewSheetDirectory())
+    if err != nil {
+        log.Println(err)
+        return
+    }
+
+    for _, f := range files {
+        if strings.HasPrefix(f.Name(), prefix + "-") && f.Name() != filepath.Base(current) {
+            os.Remove(filepath.Join(falcon.previewSheetDirectory(), f.Name()))
+        }
+    }
+}
+
+//Returns a sheet of the user's apps drawn with the pack's icons, or false when there is nothing to draw
+func (falcon *Falcon) previewSheet(iconPack IconPack) (string, bool) {
+    icons := falcon.previewSheetIcons(iconPack)
+    if len(icons) == 0 {
+        return "", false
+    }
+
+    path := falcon.previewSheetPath(iconPack, icons)
+    if _, err := os.Stat(path); err == nil {
+        return path, true
+    }
+
+    if err := falcon.renderPreviewSheet(icons, path); err != nil {
+        log.Printf("Could not render the preview sheet of %s: %s", iconPack.Title, err)
+        return "", false
+    }
+
+    falcon.cleanPreviewSheets(path)
+
+    return path, true
+}
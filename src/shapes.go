@@ -0,0 +1,265 @@
+package main
+
+import (
+    "crypto/sha1"
+    "fmt"
+    "image"
+    "image/color"
+    "image/draw"
+    "image/png"
+    "io/ioutil"
+    "log"
+    "math"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+//Indexed by the icon_shape setting
+var iconShapes = []string{"none", "circle", "rounded", "squircle"}
+
+//The size of the processed icons and the space left around the icon inside the shape
+const iconShapeSize = 256
+const iconShapePadding = 0.06
+
+//Bump this when the rendering changes so the cached icons get rendered again
+const iconShapeVersion = 1
+
+//Checks if the point is inside the shape, x & y go from -1 to 1
+func iconShapeContains(shape string, x float64, y float64) bool {
+    x = math.Abs(x)
+    y = math.Abs(y)
+
+    if shape == "circle" {
+        return x * x + y * y <= 1
+    } else if shape == "rounded" {
+        radius := 0.4
+        if x <= 1 - radius || y <= 1 - radius {
+            return x <= 1 && y <= 1
+        }
+
+        dx := x - (1 - radius)
+        dy := y - (1 - radius)
+        return dx * dx + dy * dy <= radius * radius
+    } else if shape == "squircle" {
+        return x * x * x * x + y * y * y * y <= 1
+    }
+
+    return x <= 1 && y <= 1
+}
+
+//How much of the pixel is covered by the shape inside the padding, sampled 4x4 times for smooth edges
+func iconShapeCoverage(shape string, px int, py int, size int) float64 {
+    inner := 1 - 2 * iconShapePadding
+
+    inside := 0
+    for sy := 0; sy < 4; sy++ {
+        for sx := 0; sx < 4; sx++ {
+            x := ((float64(px) + (float64(sx) + 0.5) / 4) / float64(size) * 2 - 1) / inner
+            y := ((float64(py) + (float64(sy) + 0.5) / 4) / float64(size) * 2 - 1) / inner
+
+            if iconShapeContains(shape, x, y) {
+                inside++
+            }
+        }
+    }
+
+    return float64(inside) / 16
+}
+
+//The part of the image that isn't (almost) transparent
+func contentBounds(img *image.RGBA) image.Rectangle {
+    bounds := img.Bounds()
+    content := image.Rectangle{}
+
+    for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+        for x := bounds.Min.X; x < bounds.Max.X; x++ {
+            if img.RGBAAt(x, y).A > 8 {
+                content = content.Union(image.Rect(x, y, x + 1, y + 1))
+            }
+        }
+    }
+
+    if content.Empty() {
+        return bounds
+    }
+
+    return content
+}
+
+//Samples the (premultiplied) image between pixels
+func sampleBilinear(img *image.RGBA, area image.Rectangle, fx float64, fy float64) [4]float64 {
+    clamp := func(value int, min int, max int) int {
+        if value < min {
+            return min
+        } else if value > max {
+            return max
+        }
+
+        return value
+    }
+
+    fx = fx - 0.5
+    fy = fy - 0.5
+    x0 := int(math.Floor(fx))
+    y0 := int(math.Floor(fy))
+    tx := fx - float64(x0)
+    ty := fy - float64(y0)
+
+    var result [4]float64
+    for _, corner := range [][3]float64{{0, 0, (1 - tx) * (1 - ty)}, {1, 0, tx * (1 - ty)}, {0, 1, (1 - tx) * ty}, {1, 1, tx * ty}} {
+        x := clamp(x0 + int(corner[0]), area.Min.X, area.Max.X - 1)
+        y := clamp(y0 + int(corner[1]), area.Min.Y, area.Max.Y - 1)
+        pixel := img.RGBAAt(x, y)
+
+        result[0] += float64(pixel.R) * corner[2]
+        result[1] += float64(pixel.G) * corner[2]
+        result[2] += float64(pixel.B) * corner[2]
+        result[3] += float64(pixel.A) * corner[2]
+    }
+
+    return result
+}
+
+//Crops the icon to its content, centers it with even padding and cuts it to the shape
+func renderShapedIcon(src image.Image, shape string) *image.RGBA {
+    source := image.NewRGBA(src.Bounds())
+    draw.Draw(source, source.Bounds(), src, src.Bounds().Min, draw.Src)
+
+    content := contentBounds(source)
+    inner := float64(iconShapeSize) * (1 - 2 * iconShapePadding)
+    scale := inner / math.Max(float64(content.Dx()), float64(content.Dy()))
+
+    width := float64(content.Dx()) * scale
+    height := float64(content.Dy()) * scale
+    left := (float64(iconShapeSize) - width) / 2
+    top := (float64(iconShapeSize) - height) / 2
+
+    dest := image.NewRGBA(image.Rect(0, 0, iconShapeSize, iconShapeSize))
+    for y := 0; y < iconShapeSize; y++ {
+        for x := 0; x < iconShapeSize; x++ {
+            coverage := iconShapeCoverage(shape, x, y, iconShapeSize)
+            if coverage == 0 {
+                continue
+            }
+
+            fx := (float64(x) + 0.5 - left) / scale + float64(content.Min.X)
+            fy := (float64(y) + 0.5 - top) / scale + float64(content.Min.Y)
+            if fx < float64(content.Min.X) || fy < float64(content.Min.Y) || fx >= float64(content.Max.X) || fy >= float64(content.Max.Y) {
+                continue
+            }
+
+            pixel := sampleBilinear(source, content, fx, fy)
+            dest.SetRGBA(x, y, color.RGBA{
+                R: uint8(pixel[0] * coverage + 0.5),
+                G: uint8(pixel[1] * coverage + 0.5),
+                B: uint8(pixel[2] * coverage + 0.5),
+                A: uint8(pixel[3] * coverage + 0.5),
+            })
+        }
+    }
+
+    return dest
+}
+
+func (falcon *Falcon) shapedIconDirectory() string {
+    return filepath.Join(falcon.base.CacheDirectory(), "shaped-icons")
+}
+
+//Processed icons are named after the source path, followed by its modification time and the shape
+func (falcon *Falcon) shapedIconPath(source string, info os.FileInfo, shape string) string {
+    key := fmt.Sprintf("%d:%d:%s", iconShapeVersion, info.ModTime().UnixNano(), shape)
+
+    return filepath.Join(falcon.shapedIconDirectory(), fmt.Sprintf("%x-%x.png", sha1.Sum([]byte(source)), sha1.Sum([]byte(key))))
+}
+
+//Removes the older renderings of the same source icon
+func (falcon *Falcon) cleanShapedIcons(current string) {
+    dir := filepath.Dir(current)
+    prefix := strings.SplitN(filepath.Base(current), "-", 2)[0]
+
+    files, err := ioutil.ReadDir(dir)
+    if err != nil {
+        log.Println(err)
+        return
+    }
+
+    for _, f := range files {
+        if strings.HasPrefix(f.Name(), prefix + "-") && strings.HasSuffix(f.Name(), ".png") && f.Name() != filepath.Base(current) {
+            os.Remove(filepath.Join(dir, f.Name()))
+        }
+    }
+}
+
+func (falcon *Falcon) renderShapedIcon(source string, dest string, shape string) error {
+    file, err := iconOpen(source)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    src, _, err := image.Decode(file)
+    if err != nil {
+        return err
+    }
+
+    if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+        return err
+    }
+
+    //Written next to the destination and renamed so a half written icon is never shown, every render gets its own file
+    output, err := ioutil.TempFile(filepath.Dir(dest), filepath.Base(dest) + ".tmp")
+    if err != nil {
+        return err
+    }
+    tmp := output.Name()
+
+    err = png.Encode(output, renderShapedIcon(src, shape))
+    output.Close()
+    if err == nil {
+        err = os.Chmod(tmp, 0644)
+    }
+    if err == nil {
+        err = os.Rename(tmp, dest)
+    }
+
+    if err != nil {
+        os.Remove(tmp)
+        return err
+    }
+
+    falcon.cleanShapedIcons(dest)
+
+    return nil
+}
+
+//Returns the icon cut to the selected shape, svg icons and icons that can't be processed are returned as they are
+func (falcon *Falcon) shapeIcon(icon string) string {
+    if falcon.iconShape <= 0 || falcon.iconShape >= int64(len(iconShapes)) {
+        return icon
+    }
+    shape := iconShapes[falcon.iconShape]
+
+    source := strings.TrimPrefix(icon, "file://")
+    extension := strings.ToLower(filepath.Ext(source))
+    if extension != ".png" && extension != ".jpg" && extension != ".jpeg" {
+        return icon
+    }
+
+    info, err := iconStat(source)
+    if err != nil {
+        return icon
+    }
+
+    dest := falcon.shapedIconPath(source, info, shape)
+    if _, err := iconStat(dest); err == nil {
+        return dest
+    }
+
+    if err := falcon.renderShapedIcon(source, dest, shape); err != nil {
+        log.Printf("Could not shape the icon %s: %s", source, err)
+        return icon
+    }
+
+    return dest
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+    "image"
+    "image/color"
+    "image/png"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestIconShapeContains(t *testing.T) {
+    tests := []struct {
+        shape  string
+        x      float64
+        y      float64
+        inside bool
+    }{
+        {"none", 0, 0, true},
+        {"none", 1, -1, true},
+        {"none", 1.01, 0, false},
+        {"circle", 0, -1, true},
+        {"circle", 0.8, 0.8, false},
+        {"circle", 0.7, -0.7, true},
+        {"rounded", 1, 0.5, true},
+        {"rounded", 0.95, 0.95, false},
+        {"rounded", 0.85, 0.85, true},
+        {"squircle", 0.8, 0.8, true},
+        {"squircle", 0.9, 0.9, false},
+        {"squircle", -1, 0, true},
+    }
+
+    for _, test := range tests {
+        if inside := iconShapeContains(test.shape, test.x, test.y); inside != test.inside {
+            t.Errorf("iconShapeContains(%q, %v, %v) = %v, want %v", test.shape, test.x, test.y, inside, test.inside)
+        }
+    }
+}
+
+func TestContentBounds(t *testing.T) {
+    img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+    if bounds := contentBounds(img); bounds != img.Bounds() {
+        t.Errorf("an empty image should keep its bounds, got %v", bounds)
+    }
+
+    //Nearly transparent pixels don't count as content
+    img.SetRGBA(0, 0, color.RGBA{A: 8})
+    img.SetRGBA(2, 3, color.RGBA{R: 255, A: 255})
+    img.SetRGBA(6, 7, color.RGBA{G: 255, A: 255})
+    if bounds := contentBounds(img); bounds != image.Rect(2, 3, 7, 8) {
+        t.Errorf("unexpected content bounds %v", bounds)
+    }
+}
+
+func TestRenderShapedIcon(t *testing.T) {
+    //A small opaque square in the corner of a transparent icon
+    src := image.NewRGBA(image.Rect(0, 0, 32, 32))
+    for y := 0; y < 8; y++ {
+        for x := 0; x < 8; x++ {
+            src.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+        }
+    }
+
+    square := renderShapedIcon(src, "none")
+    if square.Bounds() != image.Rect(0, 0, iconShapeSize, iconShapeSize) {
+        t.Fatalf("unexpected size %v", square.Bounds())
+    }
+
+    //The content is scaled up and centered
+    if pixel := square.RGBAAt(iconShapeSize / 2, iconShapeSize / 2); pixel.A != 255 || pixel.R != 255 {
+        t.Errorf("the center should be covered, got %v", pixel)
+    }
+    if pixel := square.RGBAAt(iconShapeSize / 2, 20); pixel.A != 255 {
+        t.Errorf("the square should reach into the corners of the shape, got %v", pixel)
+    }
+    if pixel := square.RGBAAt(0, 0); pixel.A != 0 {
+        t.Errorf("the padding should stay transparent, got %v", pixel)
+    }
+
+    circle := renderShapedIcon(src, "circle")
+    if pixel := circle.RGBAAt(20, 20); pixel.A != 0 {
+        t.Errorf("the corner should be cut from the circle, got %v", pixel)
+    }
+    if pixel := circle.RGBAAt(iconShapeSize / 2, 20); pixel.A == 0 {
+        t.Errorf("the edge of the circle should be covered, got %v", pixel)
+    }
+}
+
+func TestRenderShapedIconFile(t *testing.T) {
+    dir, err := ioutil.TempDir("", "falcon-shapes")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    source := filepath.Join(dir, "icon.png")
+    if err := writePng(source); err != nil {
+        t.Fatal(err)
+    }
+
+    falcon := &Falcon{}
+    old := filepath.Join(dir, "shaped", "abc-old.png")
+    other := filepath.Join(dir, "shaped", "def-other.png")
+    dest := filepath.Join(dir, "shaped", "abc-new.png")
+
+    if err := falcon.renderShapedIcon(source, old, "circle"); err != nil {
+        t.Fatal(err)
+    }
+    if err := falcon.renderShapedIcon(source, other, "circle"); err != nil {
+        t.Fatal(err)
+    }
+    if err := falcon.renderShapedIcon(source, dest, "rounded"); err != nil {
+        t.Fatal(err)
+    }
+
+    file, err := os.Open(dest)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer file.Close()
+
+    if img, err := png.Decode(file); err != nil {
+        t.Error(err)
+    } else if img.Bounds().Dx() != iconShapeSize {
+        t.Errorf("unexpected size %v", img.Bounds())
+    }
+
+    //Only the older rendering of the same icon is removed, and no temporary files are left behind
+    files, err := ioutil.ReadDir(filepath.Dir(dest))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var names []string
+    for _, f := range files {
+        names = append(names, f.Name())
+    }
+
+    if len(names) != 2 || names[0] != "abc-new.png" || names[1] != "def-other.png" {
+        t.Errorf("unexpected shaped icons %v", names)
+    }
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+)
+
+//Returns the category id of where the app was installed from
+func (falcon *Falcon) sourceGroup(app Application) string {
+    group := app.Source
+
+    if !app.IsApp {
+        group = "scopes"
+    } else if app.IsDesktop {
+        group = fmt.Sprintf("libertine:%s", app.Container)
+    }
+
+    return group
+}
+
+func (falcon *Falcon) containerName(id string) string {
+    name := id
+    for _, container := range falcon.containers {
+        if container.Id == id {
+            name = container.Name
+        }
+    }
+
+    return name
+}
+
+func (falcon *Falcon) sourceTitle(group string) string {
+    title := group
+
+    if group == "click" {
+        title = "Click Packages"
+    } else if group == "system" {
+        title = "System Apps"
+    } else if group == "local" {
+        title = "Local Apps"
+    } else if group == "custom" {
+        title = "Launcher Entries"
+    } else if group == "scopes" {
+        title = "Scopes"
+    } else if strings.HasPrefix(group, "libertine:") {
+        title = fmt.Sprintf("Libertine: %s", falcon.containerName(strings.TrimPrefix(group, "libertine:")))
+    }
+
+    return title
+}
+
+func (falcon *Falcon) sourceLabel(app Application) string {
+    label := "System"
+
+    if !app.IsApp {
+        label = "Scope"
+    } else if app.IsDesktop {
+        label = fmt.Sprintf("Libertine: %s", falcon.containerName(app.Container))
+    } else if app.Source == "click" {
+        label = "Click Package"
+    } else if app.Source == "local" {
+        label = "Local"
+    } else if app.Source == "custom" {
+        label = "Launcher Entry"
+    }
+
+    return label
+}
+
+func (falcon *Falcon) sourceGroupList(appList Applications) []string {
+    used := map[string]bool{}
+    for _, app := range appList {
+        used[falcon.sourceGroup(app)] = true
+    }
+
+    groups := []string{"click", "system", "local", "custom"}
+    for _, container := range falcon.containers {
+        groups = append(groups, fmt.Sprintf("libertine:%s", container.Id))
+    }
+    groups = append(groups, "scopes")
+
+    var groupList []string
+    for _, group := range groups {
+        if used[group] {
+            groupList = append(groupList, group)
+        }
+    }
+
+    return groupList
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+)
+
+const defaultStoreUrl = "https://open-store.io"
+
+type StoreApp struct {
+    Id      string `json:"id"`
+    Name    string `json:"name"`
+    Icon    string `json:"icon"`
+    Tagline string `json:"tagline"`
+    Version string `json:"version"`
+}
+
+type StoreProvider interface {
+    Search(query string) ([]StoreApp, error)
+    LatestVersion(id string) (string, error)
+    InstallUri(app StoreApp) string
+}
+
+type OpenStore struct {
+    BaseUrl string
+    Client  *http.Client
+}
+
+type openStoreAppResponse struct {
+    Success bool     `json:"success"`
+    Data    StoreApp `json:"data"`
+    Message string   `json:"message"`
+}
+
+type openStoreResponse struct {
+    Success bool `json:"success"`
+    Data    struct {
+        Packages []StoreApp `json:"packages"`
+    } `json:"data"`
+    Message string `json:"message"`
+}
+
+func NewOpenStore(baseUrl string) *OpenStore {
+    baseUrl = strings.TrimRight(strings.TrimSpace(baseUrl), "/")
+    if baseUrl == "" {
+        baseUrl = defaultStoreUrl
+    }
+
+    return &OpenStore{
+        BaseUrl: baseUrl,
+        Client:  &http.Client{Timeout: 5 * time.Second},
+    }
+}
+
+func (store *OpenStore) get(path string, params url.Values, v interface{}) error {
+    requestUrl := store.BaseUrl + path
+    if len(params) > 0 {
+        requestUrl = requestUrl + "?" + params.Encode()
+    }
+
+    resp, err := store.Client.Get(requestUrl)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("OpenStore request to %s failed: %s", path, resp.Status)
+    }
+
+    return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (store *OpenStore) Search(query string) ([]StoreApp, error) {
+    params := url.Values{}
+    params.Set("search", query)
+    params.Set("limit", "12")
+
+    var response openStoreResponse
+    if err := store.get("/api/v3/apps", params, &response); err != nil {
+        return nil, err
+    }
+
+    if !response.Success {
+        return nil, fmt.Errorf("OpenStore search failed: %s", response.Message)
+    }
+
+    return response.Data.Packages, nil
+}
+
+func (store *OpenStore) LatestVersion(id string) (string, error) {
+    var response openStoreAppResponse
+    if err := store.get("/api/v3/apps/" + url.QueryEscape(id), nil, &response); err != nil {
+        return "", err
+    }
+
+    if !response.Success {
+        return "", fmt.Errorf("OpenStore lookup of %s failed: %s", id, response.Message)
+    }
+
+    return response.Data.Version, nil
+}
+
+func (store *OpenStore) InstallUri(app StoreApp) string {
+    return fmt.Sprintf("openstore://%s", app.Id)
+}
+
+func (falcon *Falcon) storeProvider(settings Settings) StoreProvider {
+    return NewOpenStore(settings.StoreUrl)
+}
+
+//Searches the store for apps that are not installed yet
+func (falcon *Falcon) storeSearch(query string, settings Settings, metadata *scopes.SearchMetadata, reply *scopes.SearchReply) {
+    if !settings.StoreSearch || query == "" {
+        return
+    }
+
+    if metadata.InternetConnectivity() == scopes.ConnectivityStatusDisconnected {
+        log.Println("Skipping store search, there is no internet connection")
+        return
+    }
+
+    store := falcon.storeProvider(settings)
+    storeApps, err := store.Search(query)
+    if err != nil {
+        log.Println("Error while searching the store:")
+        log.Println(err)
+        return
+    }
+
+    var category *scopes.Category
+    for _, storeApp := range storeApps {
+        if falcon.clickInstalled(storeApp.Id) > 0 {
+            continue
+        }
+
+        if category == nil {
+            category = falcon.registerCategory(reply, "get-more-apps", "Get more apps", NewCategoryTemplate("grid", "vertical", "small"))
+        }
+
+        result := scopes.NewCategorisedResult(category)
+        result.SetURI(store.InstallUri(storeApp))
+        result.SetTitle(storeApp.Name)
+        result.SetArt(storeApp.Icon)
+        result.Set("subtitle", storeApp.Tagline)
+        result.Set("storeApp", storeApp)
+        result.Set("type", "store-app")
+        result.SetInterceptActivation()
+
+        if err := reply.Push(result); err != nil {
+            log.Fatalln(err)
+        }
+    }
+}
+
+func (falcon *Falcon) storeAppPreview(result *scopes.Result, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply) error {
+    var storeApp StoreApp
+    if err := result.Get("storeApp", &storeApp); err != nil {
+        log.Println(err)
+    }
+
+    headerWidget := scopes.NewPreviewWidget("header", "header")
+    headerWidget.AddAttributeValue("title", storeApp.Name)
+    headerWidget.AddAttributeValue("subtitle", storeApp.Version)
+
+    iconWidget := scopes.NewPreviewWidget("art", "image")
+    iconWidget.AddAttributeValue("source", storeApp.Icon)
+
+    taglineWidget := scopes.NewPreviewWidget("content", "text")
+    taglineWidget.AddAttributeValue("text", storeApp.Tagline)
+
+    var buttons []ActionInfo
+    buttons = append(buttons, ActionInfo{Id: "store:install", Uri: result.URI(), Label: "Install"})
+
+    actionsWidget := scopes.NewPreviewWidget("actions", "actions")
+    actionsWidget.AddAttributeValue("actions", buttons)
+
+    return falcon.pushPreview(metadata, reply, PreviewColumns{
+        Primary: []scopes.PreviewWidget{iconWidget, headerWidget, actionsWidget},
+        Details: []scopes.PreviewWidget{taglineWidget},
+    })
+}
+
+//The store actions carry their own uri, the dash opens it
+func (falcon *Falcon) storePerformAction(result *scopes.Result, metadata *scopes.ActionMetadata, widgetId, actionId string) *scopes.ActivationResponse {
+    return scopes.NewActivationResponse(scopes.ActivationNotHandled)
+}
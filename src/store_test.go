@@ -0,0 +1,88 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func newTestStore(handler http.HandlerFunc) (*OpenStore, *httptest.Server) {
+    server := httptest.NewServer(handler)
+
+    return NewOpenStore(server.URL + "/"), server
+}
+
+func TestOpenStoreSearch(t *testing.T) {
+    store, server := newTestStore(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/api/v3/apps" || r.URL.Query().Get("search") != "weather" {
+            t.Errorf("unexpected request %s", r.URL)
+        }
+
+        fmt.Fprint(w, `{"success": true, "data": {"packages": [{"id": "weather.example", "name": "Weather", "version": "1.2"}]}}`)
+    })
+    defer server.Close()
+
+    apps, err := store.Search("weather")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(apps) != 1 || apps[0].Id != "weather.example" || apps[0].Name != "Weather" {
+        t.Errorf("unexpected apps %+v", apps)
+    }
+
+    if uri := store.InstallUri(apps[0]); uri != "openstore://weather.example" {
+        t.Errorf("unexpected install uri %s", uri)
+    }
+}
+
+func TestOpenStoreLatestVersion(t *testing.T) {
+    store, server := newTestStore(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/api/v3/apps/weather.example" {
+            t.Errorf("unexpected request %s", r.URL)
+        }
+
+        fmt.Fprint(w, `{"success": true, "data": {"id": "weather.example", "version": "1.2"}}`)
+    })
+    defer server.Close()
+
+    version, err := store.LatestVersion("weather.example")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if version != "1.2" {
+        t.Errorf("unexpected version %s", version)
+    }
+}
+
+func TestOpenStoreErrors(t *testing.T) {
+    tests := []struct {
+        name   string
+        status int
+        body   string
+    }{
+        {"non-200 status", http.StatusInternalServerError, `{"success": true}`},
+        {"not found", http.StatusNotFound, ``},
+        {"malformed json", http.StatusOK, `{"success": true, "data": {"packages": [`},
+        {"unsuccessful response", http.StatusOK, `{"success": false, "message": "broken"}`},
+    }
+
+    for _, test := range tests {
+        store, server := newTestStore(func(w http.ResponseWriter, r *http.Request) {
+            w.WriteHeader(test.status)
+            fmt.Fprint(w, test.body)
+        })
+
+        if apps, err := store.Search("weather"); err == nil {
+            t.Errorf("%s: expected a search error, got %+v", test.name, apps)
+        }
+
+        if version, err := store.LatestVersion("weather.example"); err == nil {
+            t.Errorf("%s: expected a version error, got %q", test.name, version)
+        }
+
+        server.Close()
+    }
+}
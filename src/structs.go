@@ -1,14 +1,20 @@
 package main
 
 type Settings struct {
-    Layout          int64 `json:"layout"`
-    Ids             bool  `json:"ids"`
-    SeparateDesktop bool  `json:"separate_desktop"`
-    FavoritesLayout int64 `json:"favorites_layout"`
-    FavoritesSize   int64 `json:"favorites_size"`
-    AppScopeLayout  int64 `json:"app_scope_layout"`
-    AppScopeSize    int64 `json:"app_scope_size"`
-    ShowScopes      bool  `json:"show_scopes"`
+    Layout          int64  `json:"layout"`
+    Ids             bool   `json:"ids"`
+    SeparateDesktop bool   `json:"separate_desktop"`
+    FavoritesLayout int64  `json:"favorites_layout"`
+    FavoritesSize   int64  `json:"favorites_size"`
+    AppScopeLayout  int64  `json:"app_scope_layout"`
+    AppScopeSize    int64  `json:"app_scope_size"`
+    ShowScopes      bool   `json:"show_scopes"`
+    Articles        string `json:"articles"`
+    NewApps         int64  `json:"new_apps"`
+    StoreSearch     bool   `json:"store_search"`
+    StoreUrl        string `json:"store_url"`
+    CheckUpdates    bool   `json:"check_updates"`
+    IconShape       int64  `json:"icon_shape"`
 }
 
 type ActionInfo struct {
@@ -19,15 +25,55 @@ type ActionInfo struct {
 }
 
 type Application struct {
-    Id        string
-    Title     string
-    Comment   string
-    Icon      string
-    Uri       string
-    Desktop   string
-    IsApp     bool
-    IsDesktop bool
-    Sort      string
+    Id         string
+    AppId      string
+    Title      string
+    Comment    string
+    Icon       string
+    SourceIcon string
+    IconName   string
+    Uri        string
+    Desktop    string
+    DesktopId  string
+    IsApp      bool
+    IsDesktop  bool
+    IsCustom   bool
+    Container  string
+    Source     string
+    Category   string
+    Package    string
+    Installed  int64
+    IsNew      bool
+    HasUpdate  bool
+    Sort       string
+}
+
+type PreviewState struct {
+    Editing     bool     `json:"editing"`
+    Group       bool     `json:"group"`
+    Clear       string   `json:"clear,omitempty"`
+    Uninstall   bool     `json:"uninstall"`
+    Icon        bool     `json:"icon"`
+    Exported    string   `json:"exported,omitempty"`
+    ExportError string   `json:"export_error,omitempty"`
+    Skipped     []string `json:"skipped,omitempty"`
+}
+
+type LaunchRecord struct {
+    Count int64 `json:"count"`
+    Last  int64 `json:"last"`
+}
+
+type LibertineContainer struct {
+    Id   string
+    Name string
+}
+
+type CustomEntry struct {
+    Id    string `json:"id"`
+    Title string `json:"title"`
+    Uri   string `json:"uri"`
+    Icon  string `json:"icon,omitempty"`
 }
 
 type RemoteScope struct {
@@ -47,6 +93,8 @@ type IconPack struct {
     Icon        string `json:"icon"`
     Preview     string `json:"preview"`
     Description string `json:"description,omitempty"`
+    Problems    []string `json:"problems,omitempty"`
+    Installed   int64    `json:"installed,omitempty"`
 }
 
 type LibertineApp struct {
@@ -72,3 +120,53 @@ func (slice Applications) Less(a, b int) bool {
 func (slice Applications) Swap(a, b int) {
     slice[a], slice[b] = slice[b], slice[a]
 }
+
+type AppsByInstalled struct {
+    Applications
+}
+
+func (slice AppsByInstalled) Less(a, b int) bool {
+    if slice.Applications[a].Installed == slice.Applications[b].Installed {
+        return slice.Applications.Less(a, b)
+    }
+
+    return slice.Applications[a].Installed > slice.Applications[b].Installed
+}
+
+type AppsBySize struct {
+    Applications
+    Sizes map[string]int64
+}
+
+//Apps that have not been measured yet go after the ones that have
+func (slice AppsBySize) Less(a, b int) bool {
+    sizeA, measuredA := slice.Sizes[slice.Applications[a].Package]
+    sizeB, measuredB := slice.Sizes[slice.Applications[b].Package]
+
+    if measuredA != measuredB {
+        return measuredA
+    } else if sizeA == sizeB {
+        return slice.Applications.Less(a, b)
+    }
+
+    return sizeA > sizeB
+}
+
+type AppsByHistory struct {
+    Applications
+    History map[string]LaunchRecord
+    Frequency bool
+}
+
+func (slice AppsByHistory) Less(a, b int) bool {
+    recordA := slice.History[slice.Applications[a].Id]
+    recordB := slice.History[slice.Applications[b].Id]
+
+    if slice.Frequency && recordA.Count != recordB.Count {
+        return recordA.Count > recordB.Count
+    } else if recordA.Last != recordB.Last {
+        return recordA.Last > recordB.Last
+    }
+
+    return slice.Applications.Less(a, b)
+}
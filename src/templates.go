@@ -0,0 +1,194 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "strings"
+)
+
+//These line up with the displayValues of the layout & size lists in the settings
+var categoryLayouts = []string{"grid", "carousel", "vertical-journal", "horizontal-list"}
+var cardLayouts = []string{"vertical", "vertical", "horizontal", "vertical"}
+var cardSizes = []string{"small", "medium", "large"}
+
+type TemplateComponents struct {
+    Title              string
+    Subtitle           string
+    Art                string
+    ArtAspectRatio     float64
+    Emblem             string
+    Attributes         string
+    AttributesMaxCount int
+    Summary            string
+}
+
+type CategoryTemplate struct {
+    CategoryLayout string
+    CardLayout     string
+    CardSize       string
+    CollapsedRows  int
+    Overlay        bool
+    Background     string
+    Components     TemplateComponents
+}
+
+//Creates a template with the title, subtitle, art and emblem components Falcon uses for its cards
+func NewCategoryTemplate(categoryLayout string, cardLayout string, cardSize string) CategoryTemplate {
+    return CategoryTemplate{
+        CategoryLayout: categoryLayout,
+        CardLayout:     cardLayout,
+        CardSize:       cardSize,
+        Components: TemplateComponents{
+            Title:          "title",
+            Subtitle:       "subtitle",
+            Art:            "art",
+            ArtAspectRatio: 1.13,
+            Emblem:         "emblem",
+        },
+    }
+}
+
+func (falcon *Falcon) settingsTemplate(layout int64, size int64) CategoryTemplate {
+    if layout < 0 || layout >= int64(len(categoryLayouts)) {
+        log.Printf("Unknown layout setting %d, falling back to %s", layout, categoryLayouts[0])
+        layout = 0
+    }
+
+    if size < 0 || size >= int64(len(cardSizes)) {
+        log.Printf("Unknown size setting %d, falling back to %s", size, cardSizes[0])
+        size = 0
+    }
+
+    return NewCategoryTemplate(categoryLayouts[layout], cardLayouts[layout], cardSizes[size])
+}
+
+func (template CategoryTemplate) Validate() error {
+    if !stringInList(template.CategoryLayout, categoryLayouts) {
+        return fmt.Errorf("invalid category layout: %s", template.CategoryLayout)
+    }
+
+    if template.CardLayout != "" && template.CardLayout != "vertical" && template.CardLayout != "horizontal" {
+        return fmt.Errorf("invalid card layout: %s", template.CardLayout)
+    }
+
+    if template.CardSize != "" && !stringInList(template.CardSize, cardSizes) {
+        return fmt.Errorf("invalid card size: %s", template.CardSize)
+    }
+
+    if template.CollapsedRows < 0 {
+        return fmt.Errorf("invalid collapsed rows: %d", template.CollapsedRows)
+    }
+
+    if template.Background != "" && !strings.HasPrefix(template.Background, "color:///") && !strings.HasPrefix(template.Background, "gradient:///") && !strings.HasPrefix(template.Background, "file://") {
+        return fmt.Errorf("invalid card background: %s", template.Background)
+    }
+
+    if template.Components.Title == "" {
+        return fmt.Errorf("templates need a title component")
+    }
+
+    if template.Components.ArtAspectRatio < 0 || template.Components.AttributesMaxCount < 0 {
+        return fmt.Errorf("invalid component values")
+    }
+
+    return nil
+}
+
+func (template CategoryTemplate) Json() (string, error) {
+    if err := template.Validate(); err != nil {
+        return "", err
+    }
+
+    layout := map[string]interface{}{
+        "category-layout": template.CategoryLayout,
+        "collapsed-rows":  template.CollapsedRows,
+    }
+
+    if template.CardLayout != "" {
+        layout["card-layout"] = template.CardLayout
+    }
+
+    if template.CardSize != "" {
+        layout["card-size"] = template.CardSize
+    }
+
+    if template.Overlay {
+        layout["overlay"] = true
+    }
+
+    if template.Background != "" {
+        layout["card-background"] = template.Background
+    }
+
+    components := map[string]interface{}{
+        "title": template.Components.Title,
+    }
+
+    if template.Components.Subtitle != "" {
+        components["subtitle"] = template.Components.Subtitle
+    }
+
+    if template.Components.Art != "" {
+        art := map[string]interface{}{
+            "field": template.Components.Art,
+        }
+
+        if template.Components.ArtAspectRatio > 0 {
+            art["aspect-ratio"] = template.Components.ArtAspectRatio
+        }
+
+        components["art"] = art
+    }
+
+    if template.Components.Emblem != "" {
+        components["emblem"] = template.Components.Emblem
+    }
+
+    if template.Components.Attributes != "" {
+        attributes := map[string]interface{}{
+            "field": template.Components.Attributes,
+        }
+
+        if template.Components.AttributesMaxCount > 0 {
+            attributes["max-count"] = template.Components.AttributesMaxCount
+        }
+
+        components["attributes"] = attributes
+    }
+
+    if template.Components.Summary != "" {
+        components["summary"] = template.Components.Summary
+    }
+
+    data, err := json.Marshal(map[string]interface{}{
+        "schema-version": 1,
+        "template":       layout,
+        "components":     components,
+    })
+
+    return string(data), err
+}
+
+//Registers a category, an invalid template falls back to a small grid instead of breaking the search
+func (falcon *Falcon) registerCategory(reply *scopes.SearchReply, id string, title string, template CategoryTemplate) *scopes.Category {
+    renderer, err := template.Json()
+    if err != nil {
+        log.Printf("Invalid template for category %s: %s", id, err)
+
+        renderer, _ = NewCategoryTemplate("grid", "vertical", "small").Json()
+    }
+
+    return reply.RegisterCategory(id, title, "", renderer)
+}
+
+func stringInList(str string, list []string) bool {
+    for _, item := range list {
+        if item == str {
+            return true
+        }
+    }
+
+    return false
+}
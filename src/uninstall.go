@@ -0,0 +1,108 @@
+package main
+
+import (
+    "fmt"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+)
+
+func (falcon *Falcon) uninstallPreview(app Application, metadata *scopes.ActionMetadata, reply *scopes.PreviewReply) error {
+    titleWidget := scopes.NewPreviewWidget("title", "header")
+    titleWidget.AddAttributeValue("title", "Uninstall")
+    titleWidget.AddAttributeValue("subtitle", app.Title)
+
+    version := ""
+    if manifest, err := falcon.readClickManifest(app.Package); err == nil {
+        version = manifest.Version
+    }
+
+    messageWidget := scopes.NewPreviewWidget("message", "text")
+    messageWidget.AddAttributeValue("text", fmt.Sprintf("This will remove %s %s (%s) from your device.", app.Package, version, app.Title))
+
+    var buttons []ActionInfo
+    buttons = append(buttons, ActionInfo{Id: "uninstall:remove", Label: "Uninstall"})
+    buttons = append(buttons, ActionInfo{Id: "uninstall:cancel", Label: "Cancel"})
+
+    actionsWidget := scopes.NewPreviewWidget("actions", "actions")
+    actionsWidget.AddAttributeValue("actions", buttons)
+
+    return falcon.pushPreview(metadata, reply, PreviewColumns{
+        Primary: []scopes.PreviewWidget{titleWidget, actionsWidget},
+        Details: []scopes.PreviewWidget{messageWidget},
+    })
+}
+
+func (falcon *Falcon) uninstallPerformAction(result *scopes.Result, metadata *scopes.ActionMetadata, widgetId, actionId string) *scopes.ActivationResponse {
+    var resp *scopes.ActivationResponse
+
+    var app Application
+    if err := result.Get("app", &app); err != nil {
+        log.Println(err)
+    }
+
+    if actionId == "uninstall:confirm" {
+        resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+        resp.SetScopeData(PreviewState{Uninstall: true})
+    } else if actionId == "uninstall:remove" {
+        manifest, err := falcon.readClickManifest(app.Package)
+        if err == nil {
+            err = falcon.uninstallApp(app, manifest.Version)
+        }
+
+        if err != nil {
+            log.Printf("Error while uninstalling %s:", app.Package)
+            log.Println(err)
+
+            resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+        } else {
+            //redirect to blank search
+            query := scopes.NewCannedQuery("falcon.bhdouglass_falcon", "", "")
+            resp = scopes.NewActivationResponseForQuery(query)
+        }
+    } else { //action is cancel
+        resp = scopes.NewActivationResponse(scopes.ActivationShowPreview)
+    }
+
+    return resp
+}
+
+func (falcon *Falcon) uninstallApp(app Application, version string) error {
+    if err := falcon.packageManager.Uninstall(app.Package, version); err != nil {
+        return err
+    }
+
+    log.Printf("Uninstalled %s %s", app.Package, version)
+    falcon.forgetApp(app)
+
+    return nil
+}
+
+//Cleans up everything Falcon stored about an app that is no longer installed
+func (falcon *Falcon) forgetApp(app Application) {
+    if falcon.isFavorite(app.Id) {
+        falcon.unfavorite(app.Id)
+    }
+
+    if _, ok := falcon.history[app.Id]; ok {
+        delete(falcon.history, app.Id)
+        falcon.saveHistory()
+    }
+
+    if _, ok := falcon.groups[app.Id]; ok {
+        delete(falcon.groups, app.Id)
+        falcon.saveGroups()
+    }
+
+    if _, ok := falcon.dismissed[app.Id]; ok {
+        delete(falcon.dismissed, app.Id)
+        falcon.saveDismissed()
+    }
+
+    if _, ok := falcon.iconOverrides[app.Id]; ok {
+        delete(falcon.iconOverrides, app.Id)
+        falcon.saveIconOverrides()
+    }
+
+    falcon.forgetUpdate(app.Package)
+    falcon.forgetDiskUsage(app.Package)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "testing"
+    "time"
+)
+
+//Records uninstalls instead of running them, so the tests don't remove anything
+type FakePackageManager struct {
+    Uninstalled []string
+    Err         error
+}
+
+func (manager *FakePackageManager) Uninstall(pkg string, version string) error {
+    if manager.Err != nil {
+        return manager.Err
+    }
+
+    manager.Uninstalled = append(manager.Uninstalled, fmt.Sprintf("%s_%s", pkg, version))
+
+    return nil
+}
+
+func newTestUninstallFalcon(t *testing.T, manager PackageManager) (*Falcon, string) {
+    dir, err := ioutil.TempDir("", "falcon-uninstall")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    falcon := &Falcon{
+        packageManager: manager,
+        favFile: dir + "/favorites.txt",
+        historyFile: dir + "/history.json",
+        groupsFile: dir + "/groups.json",
+        dismissedFile: dir + "/dismissed.json",
+        iconOverridesFile: dir + "/icons.json",
+        updatesFile: dir + "/updates.json",
+    }
+
+    id := "com.example.app_app"
+    falcon.favorites = []string{id, "other_app"}
+    falcon.history = map[string]LaunchRecord{id: LaunchRecord{Count: 3}}
+    falcon.groups = map[string]string{id: "Games"}
+    falcon.dismissed = map[string]int64{id: 1}
+    falcon.iconOverrides = map[string]string{id: "/tmp/icon.png"}
+    falcon.updates.info = UpdateInfo{Latest: map[string]string{"com.example.app": "2.0"}}
+    falcon.diskUsage.usage = map[string]DiskUsage{"com.example.app": DiskUsage{Install: 10, Updated: time.Now().Unix()}}
+
+    return falcon, dir
+}
+
+func TestUninstallForgetsApp(t *testing.T) {
+    manager := &FakePackageManager{}
+    falcon, dir := newTestUninstallFalcon(t, manager)
+    defer os.RemoveAll(dir)
+
+    app := Application{Id: "com.example.app_app", Package: "com.example.app"}
+    if err := falcon.uninstallApp(app, "1.0"); err != nil {
+        t.Fatal(err)
+    }
+
+    if len(manager.Uninstalled) != 1 || manager.Uninstalled[0] != "com.example.app_1.0" {
+        t.Errorf("unexpected uninstalls %v", manager.Uninstalled)
+    }
+
+    if falcon.isFavorite(app.Id) || len(falcon.favorites) != 1 {
+        t.Errorf("the app is still a favorite: %v", falcon.favorites)
+    }
+
+    if _, ok := falcon.history[app.Id]; ok {
+        t.Error("the app is still in the history")
+    }
+
+    if _, ok := falcon.groups[app.Id]; ok {
+        t.Error("the app is still in a group")
+    }
+
+    if _, ok := falcon.dismissed[app.Id]; ok {
+        t.Error("the app is still dismissed")
+    }
+
+    if _, ok := falcon.iconOverrides[app.Id]; ok {
+        t.Error("the app still has an icon override")
+    }
+
+    if _, ok := falcon.updates.info.Latest[app.Package]; ok {
+        t.Error("the app still has an update")
+    }
+
+    if _, ok := falcon.cachedDiskUsage(app.Package); ok {
+        t.Error("the disk usage of the app is still cached")
+    }
+
+    //The cleanup is saved too
+    falcon.loadHistory()
+    falcon.loadIconOverrides()
+    falcon.loadUpdates()
+    if len(falcon.history) != 0 || len(falcon.iconOverrides) != 0 || len(falcon.updates.info.Latest) != 0 {
+        t.Error("the saved files still have the app")
+    }
+}
+
+func TestFailedUninstallKeepsApp(t *testing.T) {
+    manager := &FakePackageManager{Err: errors.New("click unregister failed")}
+    falcon, dir := newTestUninstallFalcon(t, manager)
+    defer os.RemoveAll(dir)
+
+    app := Application{Id: "com.example.app_app", Package: "com.example.app"}
+    if err := falcon.uninstallApp(app, "1.0"); err == nil {
+        t.Fatal("expected the uninstall to fail")
+    }
+
+    if !falcon.isFavorite(app.Id) {
+        t.Error("the app was forgotten after a failed uninstall")
+    }
+
+    if _, ok := falcon.history[app.Id]; !ok {
+        t.Error("the history was cleared after a failed uninstall")
+    }
+}
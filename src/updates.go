@@ -0,0 +1,249 @@
+package main
+
+import (
+    "encoding/json"
+    "io/ioutil"
+    "launchpad.net/go-unityscopes/v2"
+    "log"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+    "unicode"
+)
+
+//How often the installed versions are compared with the store
+const updateCheckInterval = 6 * 60 * 60
+
+//How long to wait before trying again when the store could not be reached at all
+const updateRetryInterval = 30 * 60
+
+type UpdateInfo struct {
+    Latest  map[string]string `json:"latest"`
+    Checked int64             `json:"checked"`
+}
+
+//The version of an installed package, read from its manifest
+type InstalledVersion struct {
+    Version   string
+    Installed int64
+}
+
+type UpdateCache struct {
+    sync.Mutex
+    info      UpdateInfo
+    running   bool
+    failed    int64
+    installed map[string]InstalledVersion
+}
+
+func versionParts(version string) []string {
+    return strings.FieldsFunc(version, func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+}
+
+//Compares two click versions part by part, returns 1 when a is newer, -1 when b is newer and 0 when they are the same
+//Missing parts count as 0, so 1.0 and 1.0.0 are the same version
+func compareVersions(a string, b string) int {
+    aParts := versionParts(a)
+    bParts := versionParts(b)
+
+    for index := 0; index < len(aParts) || index < len(bParts); index++ {
+        aPart := "0"
+        if index < len(aParts) {
+            aPart = aParts[index]
+        }
+
+        bPart := "0"
+        if index < len(bParts) {
+            bPart = bParts[index]
+        }
+
+        aNumber, aErr := strconv.ParseInt(aPart, 10, 64)
+        bNumber, bErr := strconv.ParseInt(bPart, 10, 64)
+        if aErr == nil && bErr == nil {
+            if aNumber != bNumber {
+                if aNumber > bNumber {
+                    return 1
+                }
+
+                return -1
+            }
+        } else if aPart != bPart {
+            if aPart > bPart {
+                return 1
+            }
+
+            return -1
+        }
+    }
+
+    return 0
+}
+
+func (falcon *Falcon) installedPackages() []string {
+    var packages []string
+
+    files, err := ioutil.ReadDir(clickDirectory)
+    if err != nil {
+        log.Println(err)
+        return packages
+    }
+
+    for _, f := range files {
+        if f.IsDir() && falcon.clickInstalled(f.Name()) > 0 {
+            packages = append(packages, f.Name())
+        }
+    }
+
+    return packages
+}
+
+//Starts a background check against the store when the last one is too old, the search never waits for it
+func (falcon *Falcon) requestUpdateCheck(settings Settings, metadata *scopes.SearchMetadata) {
+    if !settings.CheckUpdates {
+        return
+    }
+
+    if metadata.InternetConnectivity() == scopes.ConnectivityStatusDisconnected {
+        return
+    }
+
+    falcon.updates.Lock()
+    defer falcon.updates.Unlock()
+
+    now := time.Now().Unix()
+    if falcon.updates.running || falcon.updates.info.Checked > now - updateCheckInterval || falcon.updates.failed > now - updateRetryInterval {
+        return
+    }
+
+    falcon.updates.running = true
+    store := falcon.storeProvider(settings)
+
+    go falcon.checkUpdates(store, falcon.installedPackages())
+}
+
+//Looks up the latest versions, a failed lookup keeps the version found by the previous check
+func (falcon *Falcon) checkUpdates(store StoreProvider, packages []string) {
+    falcon.updates.Lock()
+    previous := falcon.updates.info.Latest
+    falcon.updates.Unlock()
+
+    latest := map[string]string{}
+    failures := 0
+    for _, pkg := range packages {
+        version, err := store.LatestVersion(pkg)
+        if err != nil {
+            //Apps that were not installed from the store can't be checked
+            log.Println(err)
+            failures++
+
+            if version, ok := previous[pkg]; ok {
+                latest[pkg] = version
+            }
+        } else if version != "" {
+            latest[pkg] = version
+        }
+    }
+
+    falcon.updates.Lock()
+    defer falcon.updates.Unlock()
+
+    falcon.updates.running = false
+
+    //The store is most likely unreachable, so the check is not counted and is tried again sooner
+    if len(packages) > 0 && failures == len(packages) {
+        falcon.updates.failed = time.Now().Unix()
+        return
+    }
+
+    falcon.updates.info = UpdateInfo{Latest: latest, Checked: time.Now().Unix()}
+    falcon.updates.failed = 0
+    falcon.saveUpdates()
+}
+
+func (falcon *Falcon) hasUpdate(app Application) bool {
+    if app.Package == "" {
+        return false
+    }
+
+    falcon.updates.Lock()
+    latest, ok := falcon.updates.info.Latest[app.Package]
+    falcon.updates.Unlock()
+
+    if !ok {
+        return false
+    }
+
+    version, err := falcon.installedVersion(app)
+    if err != nil {
+        log.Println(err)
+        return false
+    }
+
+    return compareVersions(latest, version) > 0
+}
+
+//Reads the version from the manifest, it is only read again after the package was installed or updated
+func (falcon *Falcon) installedVersion(app Application) (string, error) {
+    falcon.updates.Lock()
+    installed, ok := falcon.updates.installed[app.Package]
+    falcon.updates.Unlock()
+
+    if ok && installed.Installed == app.Installed {
+        return installed.Version, nil
+    }
+
+    manifest, err := falcon.readClickManifest(app.Package)
+    if err != nil {
+        return "", err
+    }
+
+    falcon.updates.Lock()
+    if falcon.updates.installed == nil {
+        falcon.updates.installed = map[string]InstalledVersion{}
+    }
+    falcon.updates.installed[app.Package] = InstalledVersion{Version: manifest.Version, Installed: app.Installed}
+    falcon.updates.Unlock()
+
+    return manifest.Version, nil
+}
+
+func (falcon *Falcon) forgetUpdate(pkg string) {
+    falcon.updates.Lock()
+    defer falcon.updates.Unlock()
+
+    delete(falcon.updates.installed, pkg)
+    if _, ok := falcon.updates.info.Latest[pkg]; ok {
+        delete(falcon.updates.info.Latest, pkg)
+        falcon.saveUpdates()
+    }
+}
+
+func (falcon *Falcon) markUpdates(appList Applications) {
+    for index := range appList {
+        appList[index].HasUpdate = falcon.hasUpdate(appList[index])
+    }
+}
+
+//Expects the updates lock to be held
+func (falcon *Falcon) saveUpdates() {
+    if err := writeJsonFile(falcon.updatesFile, falcon.updates.info); err != nil {
+        log.Println(err)
+    }
+}
+
+func (falcon *Falcon) loadUpdates() {
+    falcon.updates.Lock()
+    defer falcon.updates.Unlock()
+
+    falcon.updates.info = UpdateInfo{Latest: map[string]string{}}
+
+    content, err := ioutil.ReadFile(falcon.updatesFile)
+    if err != nil {
+        log.Println(err)
+    } else if err := json.Unmarshal(content, &falcon.updates.info); err != nil {
+        log.Println(err)
+    }
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+    tests := []struct {
+        a      string
+        b      string
+        result int
+    }{
+        {"1.0", "1.0", 0},
+        {"1.0.0", "1.0", 0},
+        {"1.0", "1.0.0", 0},
+        {"1.0.1", "1.0", 1},
+        {"1.0", "1.0.1", -1},
+        {"1.10", "1.9", 1},
+        {"2.0", "10.0", -1},
+        {"1.0-beta", "1.0-alpha", 1},
+        {"v1.2", "v1.2.0", 0},
+    }
+
+    for _, test := range tests {
+        if result := compareVersions(test.a, test.b); result != test.result {
+            t.Errorf("compareVersions(%q, %q) = %d, want %d", test.a, test.b, result, test.result)
+        }
+    }
+}
+
+func TestHasUpdateUsesCachedVersion(t *testing.T) {
+    falcon := &Falcon{}
+    falcon.updates.info = UpdateInfo{Latest: map[string]string{"com.example.app": "1.1"}}
+    falcon.updates.installed = map[string]InstalledVersion{"com.example.app": InstalledVersion{Version: "1.0", Installed: 100}}
+
+    if !falcon.hasUpdate(Application{Package: "com.example.app", Installed: 100}) {
+        t.Error("expected an update for 1.0")
+    }
+
+    falcon.updates.installed["com.example.app"] = InstalledVersion{Version: "1.1.0", Installed: 100}
+    if falcon.hasUpdate(Application{Package: "com.example.app", Installed: 100}) {
+        t.Error("1.1.0 is the latest version")
+    }
+
+    //A reinstalled package has no readable manifest here, so the stale version is not used
+    if falcon.hasUpdate(Application{Package: "com.example.app", Installed: 200}) {
+        t.Error("the cached version was used after the package changed")
+    }
+}
+
+type testUpdateStore struct {
+    StoreProvider
+    versions map[string]string
+}
+
+func (store testUpdateStore) LatestVersion(id string) (string, error) {
+    version, ok := store.versions[id]
+    if !ok {
+        return "", fmt.Errorf("lookup of %s failed", id)
+    }
+
+    return version, nil
+}
+
+func TestCheckUpdatesKeepsFailedLookups(t *testing.T) {
+    dir, err := ioutil.TempDir("", "falcon-updates")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    falcon := &Falcon{updatesFile: filepath.Join(dir, "updates.json")}
+    falcon.updates.info = UpdateInfo{Latest: map[string]string{"a.app": "1.0", "b.app": "2.0"}, Checked: 100}
+
+    //Every lookup failing leaves the previous check untouched
+    falcon.checkUpdates(testUpdateStore{}, []string{"a.app", "b.app"})
+    if falcon.updates.info.Checked != 100 || len(falcon.updates.info.Latest) != 2 {
+        t.Errorf("a failed check replaced the update info: %+v", falcon.updates.info)
+    }
+    if falcon.updates.failed == 0 {
+        t.Error("a failed check was not recorded")
+    }
+
+    falcon.checkUpdates(testUpdateStore{versions: map[string]string{"a.app": "1.1"}}, []string{"a.app", "b.app"})
+    if falcon.updates.info.Checked == 100 || falcon.updates.failed != 0 {
+        t.Errorf("a partial check was not counted: %+v", falcon.updates.info)
+    }
+    if falcon.updates.info.Latest["a.app"] != "1.1" || falcon.updates.info.Latest["b.app"] != "2.0" {
+        t.Errorf("unexpected versions %v", falcon.updates.info.Latest)
+    }
+}